@@ -0,0 +1,340 @@
+// Package account 火币WebSocket v2资产和订单推送客户端
+// 连接/ws/v2并使用签名版本2.1鉴权，订阅orders#${symbol}、accounts.update#${mode}和trade.clearing#${symbol}#${mode}等主题，断线重连后自动重新鉴权和订阅
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/debug"
+	"github.com/leizongmin/huobiapi/market"
+)
+
+// Endpoint 资产和订单推送的WebSocket入口，可通过WithEndpoint为每个Client实例单独指定
+var Endpoint = "wss://api.huobi.pro/ws/v2"
+
+// ConnectionClosedError Websocket未连接错误，与market.ConnectionClosedError相同
+var ConnectionClosedError = market.ConnectionClosedError
+
+// HeartbeatTimeoutError 超过两个心跳周期没有收到ping，与market.HeartbeatTimeoutError相同
+var HeartbeatTimeoutError = market.HeartbeatTimeoutError
+
+// Listener 推送数据监听器，data为推送消息中的data字段
+type Listener = func(ch string, data json.RawMessage)
+
+type Client struct {
+	// 连接、重连和心跳检查，与market.Market相同
+	conn *market.Conn
+
+	// listeners和subscribing由listenerMutex保护
+	listeners     map[string][]Listener
+	listenerMutex sync.Mutex
+	// 正在等待订阅结果的主题，期间添加的监听器等待同一个结果
+	subscribing map[string]*subscribeResult
+
+	resultCb      map[string]chan *message
+	resultCbMutex sync.Mutex
+
+	// 连接参数，创建后不再修改
+	sign              *client.Sign
+	host              string
+	path              string
+	heartbeatInterval time.Duration
+	receiveTimeout    time.Duration
+}
+
+// subscribeResult 一次订阅指令的结果，done关闭后err有效
+type subscribeResult struct {
+	done chan struct{}
+	err  error
+}
+
+// NewClient 创建Client实例，连接到入口地址并完成鉴权
+func NewClient(accessKeyId, accessKeySecret string, options ...Option) (*Client, error) {
+	o := newOptions(options)
+	u, err := url.Parse(o.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	sign := client.NewSign(accessKeyId, accessKeySecret)
+	sign.Clock = o.clock
+	c := &Client{
+		listeners:         make(map[string][]Listener),
+		subscribing:       make(map[string]*subscribeResult),
+		resultCb:          make(map[string]chan *message),
+		sign:              sign,
+		host:              strings.ToLower(u.Host),
+		path:              u.Path,
+		heartbeatInterval: o.heartbeatInterval,
+		receiveTimeout:    o.receiveTimeout,
+	}
+	c.conn = market.NewConn(market.ConnConfig{
+		Endpoint:        o.endpoint,
+		Dialer:          o.dialer,
+		Header:          o.header,
+		ReconnectPolicy: o.reconnect,
+		Hooks:           o.hooks,
+		OnOpen:          c.open,
+		Resubscribe:     c.resubscribe,
+	})
+	if err := c.conn.Connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// open 建立连接后开始处理消息并检查心跳，然后鉴权，鉴权失败时关闭连接
+func (c *Client) open(ws *market.SafeWebSocket) error {
+	ws.Listen(func(b []byte) {
+		c.handleMessage(ws, b)
+	})
+	// 服务器主动发送ping，只需检查是否超时
+	c.conn.KeepAlive(ws, c.heartbeatInterval, nil)
+	return c.auth(ws)
+}
+
+// auth 发送鉴权请求并等待结果
+func (c *Client) auth(ws *market.SafeWebSocket) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.receiveTimeout)
+	defer cancel()
+	params := c.sign.WebSocketAuth(c.host, c.path, c.sign.Timestamp())
+	_, err := c.call(ctx, ws, request{Action: "req", Ch: "auth", Params: params})
+	return err
+}
+
+// resubscribe 重连并鉴权后重新订阅所有主题，返回重新订阅成功的主题
+func (c *Client) resubscribe() []string {
+	c.listenerMutex.Lock()
+	var topics []string
+	for ch := range c.listeners {
+		topics = append(topics, ch)
+	}
+	c.listenerMutex.Unlock()
+
+	ws := c.conn.Current()
+	var resubscribed []string
+	for _, ch := range topics {
+		ctx, cancel := context.WithTimeout(context.Background(), c.receiveTimeout)
+		_, err := c.call(ctx, ws, request{Action: "sub", Ch: ch})
+		cancel()
+		if err != nil {
+			debug.Println("resubscribe failed", ch, err)
+			continue
+		}
+		resubscribed = append(resubscribed, ch)
+	}
+	return resubscribed
+}
+
+// send 通过指定连接发送消息
+func (c *Client) send(ws *market.SafeWebSocket, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	debug.Println("sendMessage", string(b))
+	ws.Send(b)
+	return nil
+}
+
+// call 通过指定连接发送指令并等待结果，返回码不为200时返回*client.APIError
+func (c *Client) call(ctx context.Context, ws *market.SafeWebSocket, req request) (*message, error) {
+	id := resultID(req.Action, req.Ch)
+	result := make(chan *message, 1)
+	c.resultCbMutex.Lock()
+	c.resultCb[id] = result
+	c.resultCbMutex.Unlock()
+	defer func() {
+		c.resultCbMutex.Lock()
+		if c.resultCb[id] == result {
+			delete(c.resultCb, id)
+		}
+		c.resultCbMutex.Unlock()
+	}()
+
+	if err := c.send(ws, req); err != nil {
+		return nil, err
+	}
+	select {
+	case msg := <-result:
+		if msg.Code != 200 {
+			return msg, newAPIError(req.Ch, msg)
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-ws.Done():
+		return nil, ConnectionClosedError
+	}
+}
+
+// handleMessage 处理连接ws收到的消息
+func (c *Client) handleMessage(ws *market.SafeWebSocket, b []byte) {
+	debug.Println("readMessage", string(b))
+	var msg message
+	if err := json.Unmarshal(b, &msg); err != nil {
+		debug.Println(err)
+		return
+	}
+
+	switch msg.Action {
+	case "ping":
+		// 收到ping后回复相同时间戳的pong
+		var ping pingData
+		if err := json.Unmarshal(msg.Data, &ping); err != nil {
+			debug.Println(err)
+			return
+		}
+		c.conn.Touch()
+		c.send(ws, request{Action: "pong", Data: ping})
+	case "push":
+		c.listenerMutex.Lock()
+		listeners := c.listeners[msg.Ch]
+		c.listenerMutex.Unlock()
+		for _, fn := range listeners {
+			fn(msg.Ch, msg.Data)
+		}
+	case "req", "sub", "unsub":
+		c.resultCbMutex.Lock()
+		id := resultID(msg.Action, msg.Ch)
+		result, ok := c.resultCb[id]
+		delete(c.resultCb, id)
+		c.resultCbMutex.Unlock()
+		if ok {
+			// 通道有缓冲，调用者已放弃等待时也不会阻塞
+			result <- &msg
+		}
+	}
+}
+
+// Subscribe 订阅主题，同一主题可以添加多个监听器，只在添加第一个监听器时发送订阅指令并等待结果
+// 订阅结果返回之前添加的监听器等待同一个结果，订阅失败时移除所有等待中的监听器并返回错误
+func (c *Client) Subscribe(ch string, listener Listener) error {
+	debug.Println("subscribe", ch)
+	c.listenerMutex.Lock()
+	_, subscribed := c.listeners[ch]
+	c.listeners[ch] = append(c.listeners[ch], listener)
+	result := c.subscribing[ch]
+	if !subscribed {
+		result = &subscribeResult{done: make(chan struct{})}
+		c.subscribing[ch] = result
+	}
+	c.listenerMutex.Unlock()
+	if subscribed {
+		if result == nil {
+			return nil
+		}
+		<-result.done
+		return result.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.receiveTimeout)
+	defer cancel()
+	_, err := c.call(ctx, c.conn.Current(), request{Action: "sub", Ch: ch})
+	c.listenerMutex.Lock()
+	// 等待期间可能已经取消订阅并重新订阅
+	if c.subscribing[ch] == result {
+		delete(c.subscribing, ch)
+		if err != nil {
+			delete(c.listeners, ch)
+		}
+	}
+	result.err = err
+	close(result.done)
+	c.listenerMutex.Unlock()
+	return err
+}
+
+// SubscribeOrders 订阅symbol的订单更新，symbol为*时订阅所有交易对
+func (c *Client) SubscribeOrders(symbol string, listener func(e *OrderUpdate)) error {
+	return c.Subscribe("orders#"+symbol, func(ch string, data json.RawMessage) {
+		var e OrderUpdate
+		if err := json.Unmarshal(data, &e); err != nil {
+			debug.Println("decode failed", ch, err)
+			return
+		}
+		listener(&e)
+	})
+}
+
+// SubscribeAccounts 订阅账户变动，mode可选AccountsModeBalance、AccountsModeAvailable和AccountsModeBoth
+func (c *Client) SubscribeAccounts(mode int, listener func(e *AccountUpdate)) error {
+	return c.Subscribe("accounts.update#"+strconv.Itoa(mode), func(ch string, data json.RawMessage) {
+		var e AccountUpdate
+		if err := json.Unmarshal(data, &e); err != nil {
+			debug.Println("decode failed", ch, err)
+			return
+		}
+		if e.Currency == "" {
+			// 忽略不包含账户数据的消息
+			return
+		}
+		listener(&e)
+	})
+}
+
+// SubscribeTradeClearing 订阅symbol清算后的成交明细，symbol为*时订阅所有交易对，mode为0时仅推送成交事件，为1时同时推送撤单事件
+func (c *Client) SubscribeTradeClearing(symbol string, mode int, listener func(e *TradeClearing)) error {
+	return c.Subscribe("trade.clearing#"+symbol+"#"+strconv.Itoa(mode), func(ch string, data json.RawMessage) {
+		var e TradeClearing
+		if err := json.Unmarshal(data, &e); err != nil {
+			debug.Println("decode failed", ch, err)
+			return
+		}
+		listener(&e)
+	})
+}
+
+// Unsubscribe 取消订阅，移除该主题的所有监听器，不能在监听器中调用
+func (c *Client) Unsubscribe(ch string) error {
+	debug.Println("unSubscribe", ch)
+	c.listenerMutex.Lock()
+	_, subscribed := c.listeners[ch]
+	delete(c.listeners, ch)
+	c.listenerMutex.Unlock()
+	if !subscribed {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.receiveTimeout)
+	defer cancel()
+	_, err := c.call(ctx, c.conn.Current(), request{Action: "unsub", Ch: ch})
+	return err
+}
+
+// Loop 进入循环，连接断开时自动重连，直到执行Close()或放弃重连才退出
+func (c *Client) Loop() {
+	c.conn.Loop()
+}
+
+// ReConnect 重新连接，按重连策略重试，之前执行过Close()或放弃重连时恢复自动重连
+func (c *Client) ReConnect() error {
+	return c.conn.ReConnect()
+}
+
+// Close 关闭连接，之后不再自动重连
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// resultID 等待结果的标识，同一主题同一时间只能有一个相同的指令在等待结果
+func resultID(action, ch string) string {
+	return action + " " + ch
+}
+
+// newAPIError 根据返回码不为200的消息创建client.APIError
+func newAPIError(ch string, msg *message) *client.APIError {
+	body, _ := json.Marshal(msg)
+	return &client.APIError{
+		Code:    strconv.Itoa(msg.Code),
+		Message: msg.Message,
+		Path:    ch,
+		Body:    body,
+	}
+}
@@ -0,0 +1,337 @@
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/market"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer 模拟/ws/v2的服务器，独立校验鉴权签名
+type testServer struct {
+	*httptest.Server
+	endpoint string
+	secret   string
+	// 回复sub之前的延迟
+	subDelay time.Duration
+
+	mutex sync.Mutex
+	conns []*testConn
+	auths int
+	subs  map[string]int
+	pongs []int64
+}
+
+type testConn struct {
+	ws     *websocket.Conn
+	mutex  sync.Mutex
+	authed bool
+}
+
+func (c *testConn) write(v interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ws.WriteJSON(v)
+}
+
+func newTestServer(secret string) *testServer {
+	s := &testServer{secret: secret, subs: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.endpoint = "ws" + strings.TrimPrefix(s.URL, "http") + "/ws/v2"
+	return s
+}
+
+func (s *testServer) handle(w http.ResponseWriter, r *http.Request) {
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &testConn{ws: ws}
+	s.mutex.Lock()
+	s.conns = append(s.conns, c)
+	s.mutex.Unlock()
+	defer ws.Close()
+
+	for {
+		var req struct {
+			Action string `json:"action"`
+			Ch     string `json:"ch"`
+			Params map[string]string
+			Data   pingData
+		}
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+		switch req.Action {
+		case "req":
+			if !s.verify(r.Host, r.URL.Path, req.Params) {
+				c.write(map[string]interface{}{"action": "req", "code": 4002, "ch": "auth", "message": "auth.fail"})
+				continue
+			}
+			s.mutex.Lock()
+			s.auths++
+			s.mutex.Unlock()
+			c.authed = true
+			c.write(map[string]interface{}{"action": "req", "code": 200, "ch": "auth", "data": map[string]string{}})
+			c.write(map[string]interface{}{"action": "ping", "data": map[string]int64{"ts": 1568789940000}})
+		case "sub", "unsub":
+			if req.Action == "sub" {
+				time.Sleep(s.subDelay)
+			}
+			if !c.authed || strings.HasPrefix(req.Ch, "invalid") {
+				c.write(map[string]interface{}{"action": req.Action, "code": 2002, "ch": req.Ch, "message": "invalid.ch"})
+				continue
+			}
+			s.mutex.Lock()
+			if req.Action == "sub" {
+				s.subs[req.Ch]++
+			} else {
+				delete(s.subs, req.Ch)
+			}
+			s.mutex.Unlock()
+			c.write(map[string]interface{}{"action": req.Action, "code": 200, "ch": req.Ch, "data": map[string]string{}})
+		case "pong":
+			s.mutex.Lock()
+			s.pongs = append(s.pongs, req.Data.Ts)
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// verify 按签名版本2.1校验鉴权参数
+func (s *testServer) verify(host, path string, params map[string]string) bool {
+	if params["authType"] != "api" || params["signatureMethod"] != "HmacSHA256" || params["signatureVersion"] != "2.1" {
+		return false
+	}
+	query := url.Values{}
+	for _, k := range []string{"accessKey", "signatureMethod", "signatureVersion", "timestamp"} {
+		query.Set(k, params[k])
+	}
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte("GET\n" + host + "\n" + path + "\n" + query.Encode()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(params["signature"]))
+}
+
+func (s *testServer) push(ch, data string) {
+	for _, c := range s.connList() {
+		c.write(map[string]interface{}{"action": "push", "ch": ch, "data": json.RawMessage(data)})
+	}
+}
+
+func (s *testServer) closeConnections() {
+	for _, c := range s.connList() {
+		c.ws.Close()
+	}
+}
+
+func (s *testServer) connList() []*testConn {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*testConn(nil), s.conns...)
+}
+
+func (s *testServer) stats() (auths int, subs map[string]int, pongs []int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	subs = make(map[string]int)
+	for k, v := range s.subs {
+		subs[k] = v
+	}
+	return s.auths, subs, append([]int64(nil), s.pongs...)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	if !markettest.WaitFor(5*time.Second, cond) {
+		t.Fatal("timeout")
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer("secret")
+	defer server.Close()
+
+	c, err := NewClient("key", "secret", WithEndpoint(server.endpoint))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// 鉴权后收到ping，回复相同时间戳的pong
+	waitFor(t, func() bool {
+		_, _, pongs := server.stats()
+		return len(pongs) == 1
+	})
+	_, _, pongs := server.stats()
+	assert.Equal(t, int64(1568789940000), pongs[0])
+
+	orders := make(chan *OrderUpdate, 1)
+	accounts := make(chan *AccountUpdate, 1)
+	clearing := make(chan *TradeClearing, 1)
+	assert.NoError(t, c.SubscribeOrders("btcusdt", func(e *OrderUpdate) { orders <- e }))
+	assert.NoError(t, c.SubscribeAccounts(AccountsModeBoth, func(e *AccountUpdate) { accounts <- e }))
+	assert.NoError(t, c.SubscribeTradeClearing("*", 0, func(e *TradeClearing) { clearing <- e }))
+	auths, subs, _ := server.stats()
+	assert.Equal(t, 1, auths)
+	assert.Equal(t, map[string]int{"orders#btcusdt": 1, "accounts.update#2": 1, "trade.clearing#*#0": 1}, subs)
+
+	server.push("orders#btcusdt", `{"eventType":"trade","symbol":"btcusdt","orderId":123,"tradePrice":"10000.5","tradeVolume":"0.01","orderStatus":"partial-filled","aggressor":true,"remainAmt":"0.09"}`)
+	o := <-orders
+	assert.Equal(t, EventTrade, o.EventType)
+	assert.Equal(t, int64(123), o.OrderID)
+	assert.Equal(t, "10000.5", o.TradePrice.String())
+	assert.Equal(t, "0.09", o.RemainAmt.String())
+	assert.True(t, o.Aggressor)
+
+	server.push("accounts.update#2", `{}`)
+	server.push("accounts.update#2", `{"currency":"usdt","accountId":100,"balance":"20.5","available":"","changeType":"order.place","accountType":"trade","changeTime":1568601800000}`)
+	a := <-accounts
+	assert.Equal(t, "usdt", a.Currency)
+	assert.Equal(t, "20.5", a.Balance.String())
+	assert.True(t, a.Available.IsZero())
+
+	server.push("trade.clearing#*#0", `{"eventType":"trade","symbol":"btcusdt","orderId":123,"tradeId":9,"transactFee":"0.0002","feeCurrency":"btc"}`)
+	tc := <-clearing
+	assert.Equal(t, int64(9), tc.TradeID)
+	assert.Equal(t, "0.0002", tc.TransactFee.String())
+
+	// 订阅失败时返回client.APIError
+	err = c.Subscribe("invalid#btcusdt", func(ch string, data json.RawMessage) {})
+	if assert.IsType(t, &client.APIError{}, err) {
+		assert.Equal(t, "2002", err.(*client.APIError).Code)
+		assert.Equal(t, "invalid.ch", err.(*client.APIError).Message)
+	}
+
+	assert.NoError(t, c.Unsubscribe("orders#btcusdt"))
+	_, subs, _ = server.stats()
+	assert.Equal(t, 0, subs["orders#btcusdt"])
+}
+
+func TestClient_AuthError(t *testing.T) {
+	server := newTestServer("secret")
+	defer server.Close()
+
+	_, err := NewClient("key", "wrong", WithEndpoint(server.endpoint))
+	if assert.IsType(t, &client.APIError{}, err) {
+		assert.Equal(t, "4002", err.(*client.APIError).Code)
+		assert.Equal(t, "auth", err.(*client.APIError).Path)
+	}
+}
+
+func TestClient_ConcurrentSubscribe(t *testing.T) {
+	server := newTestServer("secret")
+	defer server.Close()
+	server.subDelay = 100 * time.Millisecond
+
+	c, err := NewClient("key", "secret", WithEndpoint(server.endpoint))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// 同时添加的监听器等待同一个订阅结果
+	subscribe := func(ch string) []error {
+		errs := make([]error, 3)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = c.Subscribe(ch, func(ch string, data json.RawMessage) {})
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	for _, err := range subscribe("invalid#btcusdt") {
+		assert.IsType(t, &client.APIError{}, err)
+	}
+	c.listenerMutex.Lock()
+	assert.Empty(t, c.listeners)
+	assert.Empty(t, c.subscribing)
+	c.listenerMutex.Unlock()
+
+	for _, err := range subscribe("orders#btcusdt") {
+		assert.NoError(t, err)
+	}
+	_, subs, _ := server.stats()
+	assert.Equal(t, map[string]int{"orders#btcusdt": 1}, subs)
+	c.listenerMutex.Lock()
+	assert.Len(t, c.listeners["orders#btcusdt"], 3)
+	c.listenerMutex.Unlock()
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	server := newTestServer("secret")
+	defer server.Close()
+
+	var mutex sync.Mutex
+	var resubscribed []string
+	c, err := NewClient("key", "secret",
+		WithEndpoint(server.endpoint),
+		WithReconnectPolicy(market.ReconnectPolicy{Delay: 10 * time.Millisecond}),
+		WithHooks(market.Hooks{
+			OnResubscribed: func(topics []string) {
+				mutex.Lock()
+				resubscribed = topics
+				mutex.Unlock()
+			},
+		}),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	orders := make(chan *OrderUpdate, 1)
+	assert.NoError(t, c.SubscribeOrders("btcusdt", func(e *OrderUpdate) { orders <- e }))
+
+	// 断线后重新鉴权并重新订阅
+	go c.Loop()
+	server.closeConnections()
+	waitFor(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(resubscribed) == 1
+	})
+	auths, subs, _ := server.stats()
+	assert.Equal(t, 2, auths)
+	assert.Equal(t, 2, subs["orders#btcusdt"])
+	assert.Equal(t, []string{"orders#btcusdt"}, resubscribed)
+
+	server.push("orders#btcusdt", `{"eventType":"cancellation","orderId":1}`)
+	assert.Equal(t, EventCancellation, (<-orders).EventType)
+}
+
+func TestClient_HeartbeatTimeout(t *testing.T) {
+	server := newTestServer("secret")
+	defer server.Close()
+
+	disconnected := make(chan error, 1)
+	c, err := NewClient("key", "secret",
+		WithEndpoint(server.endpoint),
+		WithHeartbeatInterval(50*time.Millisecond),
+		WithReconnectPolicy(market.ReconnectPolicy{Delay: time.Hour}),
+		WithHooks(market.Hooks{
+			OnDisconnected: func(err error) { disconnected <- err },
+		}),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// 服务器只在鉴权后发送一次ping，超过两个周期后断开重连
+	select {
+	case err := <-disconnected:
+		assert.Equal(t, HeartbeatTimeoutError, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,104 @@
+package account
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/market"
+)
+
+// Option 创建Client的选项
+type Option func(o *options)
+
+type options struct {
+	endpoint          string
+	dialer            *websocket.Dialer
+	header            http.Header
+	heartbeatInterval time.Duration
+	receiveTimeout    time.Duration
+	reconnect         market.ReconnectPolicy
+	hooks             market.Hooks
+	clock             client.Clock
+}
+
+// WithEndpoint 设置WebSocket入口地址，默认为创建时的Endpoint
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithDialer 设置建立连接使用的websocket.Dialer，默认为websocket.DefaultDialer
+func WithDialer(d *websocket.Dialer) Option {
+	return func(o *options) {
+		o.dialer = d
+	}
+}
+
+// WithHeader 设置建立连接时附加的请求头
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithHeartbeatInterval 设置服务器发送ping的时间间隔，默认20秒，超过两个周期没有收到ping时重新连接
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatInterval = d
+	}
+}
+
+// WithReceiveTimeout 设置等待鉴权和订阅结果的超时时间，默认10秒
+func WithReceiveTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.receiveTimeout = d
+	}
+}
+
+// WithReconnectPolicy 设置断线重连策略，默认为market.DefaultReconnectPolicy
+func WithReconnectPolicy(p market.ReconnectPolicy) Option {
+	return func(o *options) {
+		o.reconnect = p
+	}
+}
+
+// WithHooks 设置连接生命周期回调，OnResubscribed收到的是重新订阅成功的主题
+func WithHooks(h market.Hooks) Option {
+	return func(o *options) {
+		o.hooks = h
+	}
+}
+
+// WithClock 设置计算签名时间戳使用的时钟，默认为本地时钟，可设置为client.TimeSync以避免本地时间偏差导致鉴权失败
+func WithClock(c client.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		endpoint:          Endpoint,
+		dialer:            websocket.DefaultDialer,
+		heartbeatInterval: 20 * time.Second,
+		receiveTimeout:    10 * time.Second,
+		reconnect:         market.DefaultReconnectPolicy,
+		clock:             client.LocalClock,
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	if o.dialer == nil {
+		o.dialer = websocket.DefaultDialer
+	}
+	if o.clock == nil {
+		o.clock = client.LocalClock
+	}
+	return o
+}
@@ -0,0 +1,108 @@
+package account
+
+import (
+	"encoding/json"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+// 订单事件类型
+const (
+	EventCreation     = "creation"
+	EventTrade        = "trade"
+	EventCancellation = "cancellation"
+)
+
+// 账户变动推送模式
+const (
+	// AccountsModeBalance 仅在账户余额变动时推送
+	AccountsModeBalance = 0
+	// AccountsModeAvailable 在账户余额或可用余额变动时推送，分别推送
+	AccountsModeAvailable = 1
+	// AccountsModeBoth 在账户余额或可用余额变动时推送，同时包含两者
+	AccountsModeBoth = 2
+)
+
+// OrderUpdate 订单更新，orders#${symbol}主题推送的数据，不同的事件类型包含的字段不同
+type OrderUpdate struct {
+	EventType       string            `json:"eventType"`
+	Symbol          string            `json:"symbol"`
+	AccountID       int64             `json:"accountId"`
+	OrderID         int64             `json:"orderId"`
+	ClientOrderID   string            `json:"clientOrderId"`
+	OrderSide       string            `json:"orderSide"`
+	OrderPrice      data_type.Decimal `json:"orderPrice"`
+	OrderSize       data_type.Decimal `json:"orderSize"`
+	OrderValue      data_type.Decimal `json:"orderValue"`
+	Type            string            `json:"type"`
+	OrderStatus     string            `json:"orderStatus"`
+	OrderSource     string            `json:"orderSource"`
+	OrderCreateTime int64             `json:"orderCreateTime"`
+	TradePrice      data_type.Decimal `json:"tradePrice"`
+	TradeVolume     data_type.Decimal `json:"tradeVolume"`
+	TradeID         int64             `json:"tradeId"`
+	TradeTime       int64             `json:"tradeTime"`
+	Aggressor       bool              `json:"aggressor"`
+	RemainAmt       data_type.Decimal `json:"remainAmt"`
+	ExecAmt         data_type.Decimal `json:"execAmt"`
+	LastActTime     int64             `json:"lastActTime"`
+}
+
+// AccountUpdate 账户变动，accounts.update#${mode}主题推送的数据
+type AccountUpdate struct {
+	Currency    string            `json:"currency"`
+	AccountID   int64             `json:"accountId"`
+	Balance     data_type.Decimal `json:"balance"`
+	Available   data_type.Decimal `json:"available"`
+	ChangeType  string            `json:"changeType"`
+	AccountType string            `json:"accountType"`
+	ChangeTime  int64             `json:"changeTime"`
+	SeqNum      int64             `json:"seqNum"`
+}
+
+// TradeClearing 清算后的成交明细，trade.clearing#${symbol}#${mode}主题推送的数据
+type TradeClearing struct {
+	EventType       string            `json:"eventType"`
+	Symbol          string            `json:"symbol"`
+	OrderID         int64             `json:"orderId"`
+	ClientOrderID   string            `json:"clientOrderId"`
+	AccountID       int64             `json:"accountId"`
+	Source          string            `json:"source"`
+	OrderSide       string            `json:"orderSide"`
+	OrderType       string            `json:"orderType"`
+	OrderPrice      data_type.Decimal `json:"orderPrice"`
+	OrderSize       data_type.Decimal `json:"orderSize"`
+	OrderValue      data_type.Decimal `json:"orderValue"`
+	OrderStatus     string            `json:"orderStatus"`
+	OrderCreateTime int64             `json:"orderCreateTime"`
+	TradePrice      data_type.Decimal `json:"tradePrice"`
+	TradeVolume     data_type.Decimal `json:"tradeVolume"`
+	TradeID         int64             `json:"tradeId"`
+	TradeTime       int64             `json:"tradeTime"`
+	Aggressor       bool              `json:"aggressor"`
+	TransactFee     data_type.Decimal `json:"transactFee"`
+	FeeCurrency     string            `json:"feeCurrency"`
+	FeeDeduct       data_type.Decimal `json:"feeDeduct"`
+	FeeDeductType   string            `json:"feeDeductType"`
+}
+
+// message 收到的消息
+type message struct {
+	Action  string          `json:"action"`
+	Code    int             `json:"code"`
+	Ch      string          `json:"ch"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type pingData struct {
+	Ts int64 `json:"ts"`
+}
+
+// request 发送的指令
+type request struct {
+	Action string      `json:"action"`
+	Ch     string      `json:"ch"`
+	Params interface{} `json:"params,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
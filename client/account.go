@@ -0,0 +1,104 @@
+package client
+
+import (
+	"strconv"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+/// 账户类型
+const (
+	AccountTypeSpot   = "spot"
+	AccountTypeMargin = "margin"
+	AccountTypeOtc    = "otc"
+	AccountTypePoint  = "point"
+)
+
+/// 账户状态
+const (
+	AccountStateWorking = "working"
+	AccountStateLock    = "lock"
+)
+
+/// 余额类型
+const (
+	BalanceTypeTrade  = "trade"
+	BalanceTypeFrozen = "frozen"
+)
+
+/// 账户信息
+type Account struct {
+	ID      int64  `json:"id"`
+	Type    string `json:"type"`
+	SubType string `json:"subtype"`
+	State   string `json:"state"`
+}
+
+/// 账户余额
+type Balance struct {
+	ID    int64         `json:"id"`
+	Type  string        `json:"type"`
+	State string        `json:"state"`
+	List  []BalanceItem `json:"list"`
+}
+
+/// 单个币种某一类型的余额
+/// 余额使用Decimal，以免浮点数转换丢失精度
+type BalanceItem struct {
+	Currency string            `json:"currency"`
+	Type     string            `json:"type"`
+	Balance  data_type.Decimal `json:"balance"`
+}
+
+/// 单个币种的可用及冻结余额
+type CurrencyBalance struct {
+	Currency string
+	Trade    data_type.Decimal
+	Frozen   data_type.Decimal
+}
+
+/// 按币种汇总可用及冻结余额
+func (b *Balance) Currencies() map[string]*CurrencyBalance {
+	ret := make(map[string]*CurrencyBalance)
+	for _, item := range b.List {
+		cb, ok := ret[item.Currency]
+		if !ok {
+			cb = &CurrencyBalance{Currency: item.Currency}
+			ret[item.Currency] = cb
+		}
+		switch item.Type {
+		case BalanceTypeTrade:
+			cb.Trade = cb.Trade.Add(item.Balance)
+		case BalanceTypeFrozen:
+			cb.Frozen = cb.Frozen.Add(item.Balance)
+		}
+	}
+	return ret
+}
+
+/// 取指定币种的余额，不存在时返回零值
+func (b *Balance) Get(currency string) CurrencyBalance {
+	if cb, ok := b.Currencies()[currency]; ok {
+		return *cb
+	}
+	return CurrencyBalance{Currency: currency}
+}
+
+/// 查询当前用户的所有账户
+func (c *Client) GetAccounts() ([]Account, error) {
+	var ret []Account
+	if err := c.requestData("GET", "/v1/account/accounts", nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询指定账户的余额
+func (c *Client) GetBalance(accountID int64) (*Balance, error) {
+	var ret = &Balance{}
+	path := "/v1/account/accounts/" + strconv.FormatInt(accountID, 10) + "/balance"
+	if err := c.requestData("GET", path, nil, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetAccounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/account/accounts", r.URL.Path)
+		assert.NotEmpty(t, r.URL.Query().Get("Signature"))
+		w.Write([]byte(`{"status":"ok","data":[{"id":100009,"type":"spot","subtype":"","state":"working"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	accounts, err := client.GetAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []Account{{ID: 100009, Type: AccountTypeSpot, State: AccountStateWorking}}, accounts)
+}
+
+func TestClient_GetBalance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/account/accounts/100009/balance", r.URL.Path)
+		w.Write([]byte(`{"status":"ok","data":{"id":100009,"type":"spot","state":"working","list":[
+			{"currency":"usdt","type":"trade","balance":"500.009195779000000000"},
+			{"currency":"usdt","type":"frozen","balance":"328.048800000000000000"},
+			{"currency":"eos","type":"trade","balance":"1.5"},
+			{"currency":"btt","type":"trade","balance":"123456789.123456789123456789"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	balance, err := client.GetBalance(100009)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100009), balance.ID)
+	assert.Len(t, balance.List, 4)
+	usdt := balance.Get("usdt")
+	assert.Equal(t, "500.009195779000000000", usdt.Trade.String())
+	assert.Equal(t, "328.048800000000000000", usdt.Frozen.String())
+	eos := balance.Get("eos")
+	assert.Equal(t, "1.5", eos.Trade.String())
+	assert.True(t, eos.Frozen.IsZero())
+	btc := balance.Get("btc")
+	assert.Equal(t, "btc", btc.Currency)
+	assert.True(t, btc.Trade.IsZero())
+	assert.True(t, btc.Frozen.IsZero())
+	// 超过float64精度的余额保持不变
+	assert.Equal(t, "123456789.123456789123456789", balance.Get("btt").Trade.String())
+}
+
+func TestClient_GetBalanceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"error","err-code":"api-signature-not-valid","err-msg":"Signature not valid","data":null}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	_, err = client.GetBalance(100009)
+	assert.EqualError(t, err, "Signature not valid")
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/// 批量撤单每次请求最多包含的订单数
+const BatchCancelLimit = 50
+
+/// 批量下单每次请求最多包含的订单数
+const BatchPlaceLimit = 10
+
+/// 批量撤单失败的订单
+type BatchCancelFailure struct {
+	OrderID    int64
+	OrderState int
+	ErrCode    string
+	ErrMsg     string
+}
+
+/// 批量撤单结果
+type BatchCancelResult struct {
+	Success []int64
+	Failed  []BatchCancelFailure
+}
+
+type batchCancelData struct {
+	Success []string `json:"success"`
+	Failed  []struct {
+		OrderID    string `json:"order-id"`
+		OrderState int    `json:"order-state"`
+		ErrCode    string `json:"err-code"`
+		ErrMsg     string `json:"err-msg"`
+	} `json:"failed"`
+}
+
+/// 按订单ID批量撤单，超过BatchCancelLimit个时自动分多次请求
+/// 出错时返回已经完成部分的结果
+func (c *Client) BatchCancelOrders(orderIDs []int64) (*BatchCancelResult, error) {
+	ret := &BatchCancelResult{}
+	for start := 0; start < len(orderIDs); start += BatchCancelLimit {
+		end := start + BatchCancelLimit
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		ids := make([]string, 0, end-start)
+		for _, id := range orderIDs[start:end] {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+
+		var data batchCancelData
+		body := map[string]interface{}{"order-ids": ids}
+		if err := c.postJSON("/v1/order/orders/batchcancel", body, &data); err != nil {
+			return ret, err
+		}
+		for _, s := range data.Success {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return ret, err
+			}
+			ret.Success = append(ret.Success, id)
+		}
+		for _, f := range data.Failed {
+			id, err := strconv.ParseInt(f.OrderID, 10, 64)
+			if err != nil {
+				return ret, err
+			}
+			ret.Failed = append(ret.Failed, BatchCancelFailure{
+				OrderID:    id,
+				OrderState: f.OrderState,
+				ErrCode:    f.ErrCode,
+				ErrMsg:     f.ErrMsg,
+			})
+		}
+	}
+	return ret, nil
+}
+
+/// 按条件批量撤销未成交订单的条件
+type CancelOpenOrdersRequest struct {
+	AccountID int64
+	Symbol    string
+	Side      string
+	Size      int
+}
+
+/// 按条件批量撤单结果，NextID大于0表示还有符合条件的订单未撤销
+type CancelOpenOrdersResult struct {
+	SuccessCount int   `json:"success-count"`
+	FailedCount  int   `json:"failed-count"`
+	NextID       int64 `json:"next-id"`
+}
+
+/// 按条件批量撤销未成交订单
+func (c *Client) CancelOpenOrders(req CancelOpenOrdersRequest) (*CancelOpenOrdersResult, error) {
+	if req.AccountID <= 0 {
+		return nil, fmt.Errorf("invalid account-id: %d", req.AccountID)
+	}
+	body := map[string]interface{}{"account-id": strconv.FormatInt(req.AccountID, 10)}
+	if req.Symbol != "" {
+		body["symbol"] = req.Symbol
+	}
+	if req.Side != "" {
+		if req.Side != OrderSideBuy && req.Side != OrderSideSell {
+			return nil, fmt.Errorf("invalid order side: %q", req.Side)
+		}
+		body["side"] = req.Side
+	}
+	if req.Size > 0 {
+		body["size"] = req.Size
+	}
+
+	var ret = &CancelOpenOrdersResult{}
+	if err := c.postJSON("/v1/order/orders/batchCancelOpenOrders", body, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 批量下单中单个订单的结果，ErrCode为空表示下单成功
+type BatchPlaceResult struct {
+	OrderID       int64  `json:"order-id"`
+	ClientOrderID string `json:"client-order-id"`
+	ErrCode       string `json:"err-code"`
+	ErrMsg        string `json:"err-msg"`
+}
+
+/// 是否下单成功
+func (r *BatchPlaceResult) Success() bool {
+	return r.ErrCode == "" && r.OrderID > 0
+}
+
+/// 批量下单，超过BatchPlaceLimit个时自动分多次请求
+/// 下单前检查所有订单参数，任意一个不合法则不发送任何请求；出错时返回已经完成部分的结果
+func (c *Client) BatchPlaceOrders(reqs []PlaceOrderRequest) ([]BatchPlaceResult, error) {
+	reqs = append([]PlaceOrderRequest(nil), reqs...)
+	for i := range reqs {
+		if err := c.validateOrder(&reqs[i]); err != nil {
+			return nil, fmt.Errorf("order %d: %s", i, err)
+		}
+	}
+
+	var ret []BatchPlaceResult
+	for start := 0; start < len(reqs); start += BatchPlaceLimit {
+		end := start + BatchPlaceLimit
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		body := make([]ParamData, 0, end-start)
+		for i := start; i < end; i++ {
+			body = append(body, reqs[i].params())
+		}
+
+		var data []BatchPlaceResult
+		if err := c.postJSON("/v1/order/batch-orders", body, &data); err != nil {
+			return ret, err
+		}
+		ret = append(ret, data...)
+	}
+	return ret, nil
+}
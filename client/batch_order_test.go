@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_BatchCancelOrders(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v1/order/orders/batchcancel", r.URL.Path)
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var body struct {
+			OrderIDs []string `json:"order-ids"`
+		}
+		assert.NoError(t, json.Unmarshal(b, &body))
+		assert.True(t, len(body.OrderIDs) <= BatchCancelLimit)
+
+		// 第一个订单撤单失败，其余成功
+		success := body.OrderIDs
+		failed := ""
+		if body.OrderIDs[0] == "1" {
+			success = body.OrderIDs[1:]
+			failed = `{"order-id":"1","order-state":-1,"err-code":"base-record-invalid","err-msg":"record invalid"}`
+		}
+		s, _ := json.Marshal(success)
+		fmt.Fprintf(w, `{"status":"ok","data":{"success":%s,"failed":[%s]}}`, s, failed)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	ids := make([]int64, 120)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	ret, err := client.BatchCancelOrders(ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, ret.Success, 119)
+	assert.Equal(t, int64(2), ret.Success[0])
+	assert.Equal(t, []BatchCancelFailure{{OrderID: 1, OrderState: -1, ErrCode: "base-record-invalid", ErrMsg: "record invalid"}}, ret.Failed)
+}
+
+func TestClient_CancelOpenOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/order/orders/batchCancelOpenOrders", r.URL.Path)
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"account-id":"100009","symbol":"eosusdt","side":"buy","size":100}`, string(b))
+		w.Write([]byte(`{"status":"ok","data":{"success-count":2,"failed-count":0,"next-id":5454600}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	ret, err := client.CancelOpenOrders(CancelOpenOrdersRequest{AccountID: 100009, Symbol: "eosusdt", Side: OrderSideBuy, Size: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, &CancelOpenOrdersResult{SuccessCount: 2, NextID: 5454600}, ret)
+
+	_, err = client.CancelOpenOrders(CancelOpenOrdersRequest{})
+	assert.EqualError(t, err, "invalid account-id: 0")
+}
+
+func TestClient_BatchPlaceOrders(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v1/order/batch-orders", r.URL.Path)
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var body []map[string]string
+		assert.NoError(t, json.Unmarshal(b, &body))
+		assert.True(t, len(body) <= BatchPlaceLimit)
+
+		items := make([]string, len(body))
+		for i, o := range body {
+			if o["amount"] == "0" {
+				items[i] = fmt.Sprintf(`{"client-order-id":"%s","err-code":"order-value-min-error","err-msg":"value too small"}`, o["client-order-id"])
+			} else {
+				items[i] = fmt.Sprintf(`{"client-order-id":"%s","order-id":%d}`, o["client-order-id"], 1000+len(o["client-order-id"]))
+			}
+		}
+		fmt.Fprintf(w, `{"status":"ok","data":[%s]}`, strings.Join(items, ","))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	reqs := make([]PlaceOrderRequest, 12)
+	for i := range reqs {
+		reqs[i] = PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1", Price: "1", ClientOrderID: fmt.Sprintf("c%d", i)}
+	}
+	reqs[11].Amount = "0"
+	ret, err := client.BatchPlaceOrders(reqs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Len(t, ret, 12)
+	assert.True(t, ret[0].Success())
+	assert.Equal(t, "c0", ret[0].ClientOrderID)
+	assert.False(t, ret[11].Success())
+	assert.Equal(t, "order-value-min-error", ret[11].ErrCode)
+
+	reqs[3].Type = "buy"
+	_, err = client.BatchPlaceOrders(reqs)
+	assert.EqualError(t, err, `order 3: invalid order type: "buy"`)
+	assert.Equal(t, 2, requests)
+}
@@ -1,6 +1,9 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
 	"net/url"
 
 	"github.com/bitly/go-simplejson"
@@ -11,6 +14,11 @@ type Client struct {
 	host       string
 	pathPrefix string
 	scheme     string
+	ctx        context.Context
+	http       *httpConfig
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+	validator  *OrderValidator
 }
 
 /// 行情API
@@ -22,27 +30,118 @@ const TradeEndpoint = "https://api.huobi.pro/v1"
 /// 全局API
 const Endpoint = "https://api.huobi.pro"
 
-/// 创建新客户端
-func NewClient(endpoint, accessKeyId, accessKeySecret string) (*Client, error) {
+/// 创建新客户端，可通过options指定http.Client、代理、请求头等
+func NewClient(endpoint, accessKeyId, accessKeySecret string, options ...Option) (*Client, error) {
 	urlInfo, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, err
 	}
+	o := newOptions(options)
+	conf, err := newHTTPConfig(o)
+	if err != nil {
+		return nil, err
+	}
 
 	client := &Client{
 		Sign:       NewSign(accessKeyId, accessKeySecret),
 		host:       urlInfo.Host,
 		pathPrefix: urlInfo.Path,
 		scheme:     urlInfo.Scheme,
+		http:       conf,
+		retry:      o.retry,
+		limiter:    o.limiter,
+		validator:  o.validator,
 	}
 	if client.pathPrefix == "/" {
 		client.pathPrefix = ""
 	}
+	client.Sign.Clock = o.clock
+	if o.limiter != nil {
+		conf.afterResponse = func(res *http.Response) {
+			o.limiter.updateFromResponse(res, accessKeyId)
+		}
+	}
 
 	return client, nil
 }
 
 /// 发送请求
 func (c *Client) Request(method, path string, data ParamData) (*simplejson.Json, error) {
-	return SendRequest(c.Sign, method, c.scheme, c.host, c.pathPrefix+path, data)
+	return c.send(method, c.pathPrefix+path, data, nil)
+}
+
+/// 发送请求，ctx取消或超时时中断请求
+func (c *Client) RequestWithContext(ctx context.Context, method, path string, data ParamData) (*simplejson.Json, error) {
+	return c.WithContext(ctx).send(method, c.pathPrefix+path, data, nil)
+}
+
+/// 返回使用指定ctx的客户端副本，副本上的所有接口调用都会在ctx取消或超时时中断
+/// 例如：client.WithContext(ctx).GetAccounts()
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+/// 当前客户端使用的ctx，未指定时为context.Background()
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+/// 发送请求并将返回结果的data字段解析到v
+/// 类型化接口使用完整路径，不受创建客户端时endpoint中路径前缀的影响
+func (c *Client) requestData(method, path string, data ParamData, v interface{}) error {
+	ret, err := c.send(method, path, data, nil)
+	if err != nil {
+		return err
+	}
+	return decodeData(ret, v)
+}
+
+/// 发送请求并将完整的返回结果解析到v，用于行情等不使用data字段的接口
+func (c *Client) requestResult(method, path string, data ParamData, v interface{}) error {
+	ret, err := c.send(method, path, data, nil)
+	if err != nil {
+		return err
+	}
+	b, err := ret.Encode()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+/// 发送JSON内容的POST请求并将返回结果的data字段解析到v
+func (c *Client) postJSON(path string, body interface{}, v interface{}) error {
+	ret, err := c.send("POST", path, nil, body)
+	if err != nil {
+		return err
+	}
+	return decodeData(ret, v)
+}
+
+/// 发送一次请求，设置了限频器时先等待
+func (c *Client) sendOnce(method, path string, data ParamData, body interface{}) (*simplejson.Json, error) {
+	ctx := c.Context()
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, GetEndpointGroup(method, path), c.Sign.AccessKeyId); err != nil {
+			return nil, err
+		}
+	}
+	return sendRequest(ctx, c.http, c.Sign, method, c.scheme, c.host, path, data, body)
+}
+
+/// 将返回结果的data字段解析到v
+func decodeData(ret *simplejson.Json, v interface{}) error {
+	b, err := ret.Get("data").Encode()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
 }
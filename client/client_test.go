@@ -1,11 +1,16 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"os"
 
+	"github.com/leizongmin/huobiapi/client/clienttest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +24,20 @@ func TestSign(t *testing.T) {
 	assert.Equal(t, "Nmd8AU8uAe0mkFpxNbiava0aeZzBEtYjCdie1ZYZjoM=", ret)
 }
 
+func TestSign_WebSocketAuth(t *testing.T) {
+	sign := NewSign("e2xxxxxx-99xxxxxx-84xxxxxx-7xxxx", "b0xxxxxx-c6xxxxxx-94xxxxxx-dxxxx")
+	ret := sign.WebSocketAuth("api.huobi.pro", "/ws/v2", "2019-09-01T18:16:16")
+	fmt.Println(ret)
+	assert.Equal(t, &WebSocketAuthParams{
+		AuthType:         "api",
+		AccessKey:        "e2xxxxxx-99xxxxxx-84xxxxxx-7xxxx",
+		SignatureMethod:  "HmacSHA256",
+		SignatureVersion: "2.1",
+		Timestamp:        "2019-09-01T18:16:16",
+		Signature:        "axtO0jdyWXVW/kMs0WefT2OvjoacWnJte/hJOc66pW4=",
+	}, ret)
+}
+
 func TestSendRequest(t *testing.T) {
 	sign := NewSign("e2xxxxxx-99xxxxxx-84xxxxxx-7xxxx", "b0xxxxxx-c6xxxxxx-94xxxxxx-dxxxx")
 	json, err := SendRequest(sign, "GET", "https", "api.huobi.pro", "/market/history/kline", ParamData{
@@ -45,17 +64,17 @@ func TestClient_Request(t *testing.T) {
 }
 
 func TestTradeRequest(t *testing.T) {
-	accessKeyId, ok := os.LookupEnv("TEST_KEY")
-	if !ok {
-		fmt.Println("取消测试Trade接口")
-		return
+	endpoint := Endpoint
+	accessKeyId, okKey := os.LookupEnv("TEST_KEY")
+	accessKeySecret, okSecret := os.LookupEnv("TEST_SECRET")
+	if !okKey || !okSecret {
+		// 没有设置API Key时使用本地模拟服务器
+		fmt.Println("使用模拟服务器测试Trade接口")
+		server := clienttest.NewServer("key", "secret")
+		defer server.Close()
+		endpoint, accessKeyId, accessKeySecret = server.URL, "key", "secret"
 	}
-	accessKeySecret, ok := os.LookupEnv("TEST_SECRET")
-	if !ok {
-		fmt.Println("取消测试Trade接口")
-		return
-	}
-	client, err := NewClient(Endpoint, accessKeyId, accessKeySecret)
+	client, err := NewClient(endpoint, accessKeyId, accessKeySecret)
 	assert.NoError(t, err)
 	json, err := client.Request("GET", "/v1/order/matchresults", ParamData{"symbol": "eosusdt"})
 	assert.NoError(t, err)
@@ -64,3 +83,30 @@ func TestTradeRequest(t *testing.T) {
 	assert.NoError(t, err)
 	fmt.Println(string(b))
 }
+
+func TestClient_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		w.Write([]byte(`{"status":"ok","data":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start := time.Now()
+	_, err = client.WithContext(ctx).GetAccounts()
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Millisecond*500)
+
+	_, err = client.RequestWithContext(ctx, "GET", "/v1/account/accounts", nil)
+	assert.Error(t, err)
+
+	// 原客户端不受影响
+	assert.Equal(t, context.Background(), client.Context())
+	accounts, err := client.GetAccounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 0)
+}
@@ -0,0 +1,494 @@
+package clienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+// 批量接口每次请求最多包含的订单数
+const (
+	batchCancelLimit = 50
+	batchPlaceLimit  = 10
+)
+
+// 批量撤单结果中使用的订单状态编号
+var orderStateCodes = map[string]int{
+	"submitted":        3,
+	"partial-filled":   4,
+	"partial-canceled": 5,
+	"filled":           6,
+	"canceled":         7,
+}
+
+var orderTypes = map[string]bool{
+	"buy-market":       true,
+	"sell-market":      true,
+	"buy-limit":        true,
+	"sell-limit":       true,
+	"buy-ioc":          true,
+	"sell-ioc":         true,
+	"buy-limit-maker":  true,
+	"sell-limit-maker": true,
+}
+
+// order 订单，buy-market订单的amount为计价币种的金额，其余为基础币种的数量
+type order struct {
+	id            int64
+	account       *account
+	symbol        symbol
+	clientOrderID string
+	typ           string
+	state         string
+	source        string
+	price         data_type.Decimal
+	amount        data_type.Decimal
+	filledAmount  data_type.Decimal
+	filledCash    data_type.Decimal
+	createdAt     int64
+	canceledAt    int64
+	finishedAt    int64
+}
+
+// match 成交记录
+type match struct {
+	id      int64
+	matchID int64
+	order   *order
+	price   data_type.Decimal
+	amount  data_type.Decimal
+	at      int64
+}
+
+func (o *order) isBuy() bool {
+	return strings.HasPrefix(o.typ, "buy-")
+}
+
+func (o *order) side() string {
+	if o.isBuy() {
+		return "buy"
+	}
+	return "sell"
+}
+
+func (o *order) isOpen() bool {
+	return o.state == "submitted" || o.state == "partial-filled"
+}
+
+// frozenCurrency 下单时冻结的币种
+func (o *order) frozenCurrency() string {
+	if o.isBuy() {
+		return o.symbol.quote
+	}
+	return o.symbol.base
+}
+
+// remainingFrozen 未成交部分冻结的金额
+func (o *order) remainingFrozen() data_type.Decimal {
+	switch {
+	case o.typ == "buy-market":
+		return o.amount.Sub(o.filledCash)
+	case o.isBuy():
+		return o.amount.Sub(o.filledAmount).Mul(o.price)
+	default:
+		return o.amount.Sub(o.filledAmount)
+	}
+}
+
+// fields 订单详情和未成交订单共有的字段
+func (o *order) fields() map[string]interface{} {
+	return map[string]interface{}{
+		"id":              o.id,
+		"symbol":          o.symbol.symbol,
+		"account-id":      o.account.id,
+		"client-order-id": o.clientOrderID,
+		"type":            o.typ,
+		"state":           o.state,
+		"source":          o.source,
+		"price":           format(o.price),
+		"amount":          format(o.amount),
+		"created-at":      o.createdAt,
+	}
+}
+
+// detail 订单详情接口返回的内容，成交字段名为交易所原有的field-*
+func (o *order) detail() map[string]interface{} {
+	m := o.fields()
+	m["field-amount"] = format(o.filledAmount)
+	m["field-cash-amount"] = format(o.filledCash)
+	m["field-fees"] = format(data_type.Decimal{})
+	m["canceled-at"] = o.canceledAt
+	m["finished-at"] = o.finishedAt
+	return m
+}
+
+// openDetail 未成交订单接口返回的内容
+func (o *order) openDetail() map[string]interface{} {
+	m := o.fields()
+	m["filled-amount"] = format(o.filledAmount)
+	m["filled-cash-amount"] = format(o.filledCash)
+	m["filled-fees"] = format(data_type.Decimal{})
+	return m
+}
+
+// Fill 模拟订单成交，price为成交价格，amount为基础币种的成交数量
+// 成交后按成交金额更新账户余额，不收取手续费；买单成交价格不能高于委托价格，卖单不能低于委托价格
+func (s *Server) Fill(orderID int64, price, amount string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	o, ok := s.orders[orderID]
+	if !ok {
+		return fmt.Errorf("order %d not found", orderID)
+	}
+	if !o.isOpen() {
+		return fmt.Errorf("order %d is %s", orderID, o.state)
+	}
+	p, err := data_type.ParseDecimal(price)
+	if err != nil || p.Sign() <= 0 {
+		return fmt.Errorf("invalid fill price: %q", price)
+	}
+	a, err := data_type.ParseDecimal(amount)
+	if err != nil || a.Sign() <= 0 {
+		return fmt.Errorf("invalid fill amount: %q", amount)
+	}
+	if o.typ != "buy-market" && o.typ != "sell-market" {
+		if o.isBuy() && p.GreaterThan(o.price) {
+			return fmt.Errorf("fill price %s is higher than order price %s", p, o.price)
+		}
+		if !o.isBuy() && p.LessThan(o.price) {
+			return fmt.Errorf("fill price %s is lower than order price %s", p, o.price)
+		}
+	}
+	cash := p.Mul(a)
+	if o.typ == "buy-market" {
+		if o.filledCash.Add(cash).GreaterThan(o.amount) {
+			return fmt.Errorf("fill value %s exceeds remaining %s", cash, o.remainingFrozen())
+		}
+	} else if o.filledAmount.Add(a).GreaterThan(o.amount) {
+		return fmt.Errorf("fill amount %s exceeds remaining %s", a, o.amount.Sub(o.filledAmount))
+	}
+
+	acc, base, quote := o.account, o.symbol.base, o.symbol.quote
+	switch {
+	case o.typ == "buy-market":
+		acc.frozen[quote] = acc.frozen[quote].Sub(cash)
+		acc.trade[base] = acc.trade[base].Add(a)
+	case o.isBuy():
+		// 按委托价格解冻，按成交价格扣除，差额退回可用余额
+		acc.frozen[quote] = acc.frozen[quote].Sub(a.Mul(o.price))
+		acc.trade[quote] = acc.trade[quote].Add(a.Mul(o.price.Sub(p)))
+		acc.trade[base] = acc.trade[base].Add(a)
+	default:
+		acc.frozen[base] = acc.frozen[base].Sub(a)
+		acc.trade[quote] = acc.trade[quote].Add(cash)
+	}
+
+	now := timestamp(s.now())
+	o.filledAmount = o.filledAmount.Add(a)
+	o.filledCash = o.filledCash.Add(cash)
+	if o.remainingFrozen().IsZero() {
+		o.state = "filled"
+		o.finishedAt = now
+	} else {
+		o.state = "partial-filled"
+	}
+	s.nextMatch++
+	s.matches = append(s.matches, &match{
+		id:      s.nextMatch,
+		matchID: s.nextMatch + 10000000,
+		order:   o,
+		price:   p,
+		amount:  a,
+		at:      now,
+	})
+	return nil
+}
+
+// placeOrder 检查下单参数并冻结余额
+func (s *Server) placeOrder(accessKey string, params map[string]string) (*order, *apiError) {
+	accountID, _ := strconv.ParseInt(params["account-id"], 10, 64)
+	acc, ok := s.accounts[accountID]
+	if !ok || acc.accessKey != accessKey {
+		return nil, newError("account-account-id-inexistent", "account-id `%s` does not exist", params["account-id"])
+	}
+	sym, ok := s.symbols[params["symbol"]]
+	if !ok {
+		return nil, newError("base-symbol-error", "The symbol is invalid")
+	}
+	typ := params["type"]
+	if !orderTypes[typ] {
+		return nil, newError("invalid-parameter", "invalid order type: `%s`", typ)
+	}
+	o := &order{
+		account:       acc,
+		symbol:        sym,
+		clientOrderID: params["client-order-id"],
+		typ:           typ,
+		state:         "submitted",
+		source:        params["source"],
+		createdAt:     timestamp(s.now()),
+	}
+	if o.source == "" {
+		o.source = "spot-api"
+	}
+
+	amount, err := data_type.ParseDecimal(params["amount"])
+	if err != nil || amount.Sign() <= 0 {
+		return nil, newError("invalid-amount", "invalid amount: `%s`", params["amount"])
+	}
+	if typ != "buy-market" && amount.Normalize().Scale() > sym.amountPrecision {
+		return nil, newError("order-orderamount-precision-error", "order amount precision error, scale: `%d`", sym.amountPrecision)
+	}
+	o.amount = amount
+	if typ != "buy-market" && typ != "sell-market" {
+		price, err := data_type.ParseDecimal(params["price"])
+		if err != nil || price.Sign() <= 0 {
+			return nil, newError("invalid-price", "invalid price: `%s`", params["price"])
+		}
+		if price.Normalize().Scale() > sym.pricePrecision {
+			return nil, newError("order-orderprice-precision-error", "order price precision error, scale: `%d`", sym.pricePrecision)
+		}
+		o.price = price
+	}
+
+	currency, need := o.frozenCurrency(), o.remainingFrozen()
+	if acc.trade[currency].LessThan(need) {
+		return nil, newError("account-frozen-balance-insufficient-error", "trade account balance is not enough, left: `%s`", acc.trade[currency].Normalize())
+	}
+	acc.trade[currency] = acc.trade[currency].Sub(need)
+	acc.frozen[currency] = acc.frozen[currency].Add(need)
+
+	s.nextOrder++
+	o.id = s.nextOrder
+	s.orders[o.id] = o
+	return o, nil
+}
+
+// cancelOrder 撤销订单并解冻未成交部分的余额
+func (s *Server) cancelOrder(accessKey string, id int64) *apiError {
+	o := s.findOrder(accessKey, id)
+	if o == nil {
+		return newError("base-record-invalid", "record invalid")
+	}
+	if !o.isOpen() {
+		return newError("order-orderstate-error", "the order state is error")
+	}
+	currency, remaining := o.frozenCurrency(), o.remainingFrozen()
+	o.account.frozen[currency] = o.account.frozen[currency].Sub(remaining)
+	o.account.trade[currency] = o.account.trade[currency].Add(remaining)
+	if o.filledAmount.IsZero() {
+		o.state = "canceled"
+	} else {
+		o.state = "partial-canceled"
+	}
+	o.canceledAt = timestamp(s.now())
+	o.finishedAt = o.canceledAt
+	return nil
+}
+
+func (s *Server) findOrder(accessKey string, id int64) *order {
+	o, ok := s.orders[id]
+	if !ok || o.account.accessKey != accessKey {
+		return nil
+	}
+	return o
+}
+
+// findClientOrder 按client-order-id查找最新的订单
+func (s *Server) findClientOrder(accessKey, clientOrderID string) *order {
+	var ret *order
+	if clientOrderID == "" {
+		return nil
+	}
+	for _, o := range s.orders {
+		if o.clientOrderID == clientOrderID && o.account.accessKey == accessKey && (ret == nil || o.id > ret.id) {
+			ret = o
+		}
+	}
+	return ret
+}
+
+// sortedOrders 按ID升序排列满足条件的订单
+func (s *Server) sortedOrders(cond func(o *order) bool) []*order {
+	list := make([]*order, 0)
+	for _, o := range s.orders {
+		if cond(o) {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+	return list
+}
+
+func (s *Server) batchPlaceOrders(accessKey string, body json.RawMessage) (interface{}, *apiError) {
+	var list []map[string]string
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, newError("invalid-parameter", "invalid request body: %s", err)
+	}
+	if len(list) == 0 || len(list) > batchPlaceLimit {
+		return nil, newError("invalid-parameter", "the number of orders must be between 1 and %d", batchPlaceLimit)
+	}
+	ret := make([]map[string]interface{}, len(list))
+	for i, params := range list {
+		item := map[string]interface{}{"client-order-id": params["client-order-id"]}
+		if o, err := s.placeOrder(accessKey, params); err != nil {
+			item["err-code"] = err.code
+			item["err-msg"] = err.message
+		} else {
+			item["order-id"] = o.id
+		}
+		ret[i] = item
+	}
+	return ret, nil
+}
+
+func (s *Server) batchCancelOrders(accessKey string, body json.RawMessage) (interface{}, *apiError) {
+	var req struct {
+		OrderIDs []string `json:"order-ids"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, newError("invalid-parameter", "invalid request body: %s", err)
+	}
+	if len(req.OrderIDs) == 0 || len(req.OrderIDs) > batchCancelLimit {
+		return nil, newError("invalid-parameter", "the number of order-ids must be between 1 and %d", batchCancelLimit)
+	}
+	success := make([]string, 0)
+	failed := make([]map[string]interface{}, 0)
+	for _, v := range req.OrderIDs {
+		id, _ := strconv.ParseInt(v, 10, 64)
+		if err := s.cancelOrder(accessKey, id); err != nil {
+			state := -1
+			if o := s.findOrder(accessKey, id); o != nil {
+				state = orderStateCodes[o.state]
+			}
+			failed = append(failed, map[string]interface{}{
+				"order-id":    v,
+				"order-state": state,
+				"err-code":    err.code,
+				"err-msg":     err.message,
+			})
+			continue
+		}
+		success = append(success, v)
+	}
+	return map[string]interface{}{"success": success, "failed": failed}, nil
+}
+
+func (s *Server) cancelOpenOrders(accessKey string, body json.RawMessage) (interface{}, *apiError) {
+	var req struct {
+		AccountID string `json:"account-id"`
+		Symbol    string `json:"symbol"`
+		Side      string `json:"side"`
+		Size      int    `json:"size"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, newError("invalid-parameter", "invalid request body: %s", err)
+	}
+	accountID, _ := strconv.ParseInt(req.AccountID, 10, 64)
+	acc, ok := s.accounts[accountID]
+	if !ok || acc.accessKey != accessKey {
+		return nil, newError("account-account-id-inexistent", "account-id `%s` does not exist", req.AccountID)
+	}
+	if req.Size <= 0 || req.Size > 100 {
+		req.Size = 100
+	}
+	list := s.sortedOrders(func(o *order) bool {
+		return o.account == acc && o.isOpen() &&
+			(req.Symbol == "" || o.symbol.symbol == req.Symbol) &&
+			(req.Side == "" || o.side() == req.Side)
+	})
+	var nextID int64 = -1
+	if len(list) > req.Size {
+		nextID = list[req.Size].id
+		list = list[:req.Size]
+	}
+	var successCount, failedCount int
+	for _, o := range list {
+		if err := s.cancelOrder(accessKey, o.id); err != nil {
+			failedCount++
+		} else {
+			successCount++
+		}
+	}
+	return map[string]interface{}{
+		"success-count": successCount,
+		"failed-count":  failedCount,
+		"next-id":       nextID,
+	}, nil
+}
+
+func (s *Server) getOpenOrders(accessKey string, query url.Values) (interface{}, *apiError) {
+	var accountID int64
+	if v := query.Get("account-id"); v != "" {
+		accountID, _ = strconv.ParseInt(v, 10, 64)
+		if acc, ok := s.accounts[accountID]; !ok || acc.accessKey != accessKey {
+			return nil, newError("account-account-id-inexistent", "account-id `%s` does not exist", v)
+		}
+	}
+	symbol, side := query.Get("symbol"), query.Get("side")
+	size, _ := strconv.Atoi(query.Get("size"))
+	if size <= 0 || size > 500 {
+		size = 100
+	}
+	list := s.sortedOrders(func(o *order) bool {
+		return o.account.accessKey == accessKey && o.isOpen() &&
+			(accountID == 0 || o.account.id == accountID) &&
+			(symbol == "" || o.symbol.symbol == symbol) &&
+			(side == "" || o.side() == side)
+	})
+	ret := make([]map[string]interface{}, 0, size)
+	for i := len(list) - 1; i >= 0 && len(ret) < size; i-- {
+		ret = append(ret, list[i].openDetail())
+	}
+	return ret, nil
+}
+
+func (s *Server) getMatchResults(accessKey string, query url.Values) (interface{}, *apiError) {
+	symbol := query.Get("symbol")
+	if symbol == "" {
+		return nil, newError("invalid-parameter", "symbol is required")
+	}
+	var types map[string]bool
+	if v := query.Get("types"); v != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(v, ",") {
+			types[t] = true
+		}
+	}
+	size, _ := strconv.Atoi(query.Get("size"))
+	if size <= 0 || size > 100 {
+		size = 100
+	}
+	ret := make([]map[string]interface{}, 0)
+	for i := len(s.matches) - 1; i >= 0 && len(ret) < size; i-- {
+		m := s.matches[i]
+		o := m.order
+		if o.account.accessKey != accessKey || o.symbol.symbol != symbol || (types != nil && !types[o.typ]) {
+			continue
+		}
+		feeCurrency := o.symbol.quote
+		if o.isBuy() {
+			feeCurrency = o.symbol.base
+		}
+		ret = append(ret, map[string]interface{}{
+			"id":            m.id,
+			"order-id":      o.id,
+			"match-id":      m.matchID,
+			"symbol":        symbol,
+			"type":          o.typ,
+			"source":        o.source,
+			"role":          "taker",
+			"price":         format(m.price),
+			"filled-amount": format(m.amount),
+			"filled-fees":   format(data_type.Decimal{}),
+			"fee-currency":  feeCurrency,
+			"created-at":    m.at,
+		})
+	}
+	return ret, nil
+}
@@ -0,0 +1,448 @@
+// Package clienttest 提供模拟的火币REST API服务器，用于在没有网络的环境中测试基于client.Client的交易逻辑
+package clienttest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+// DefaultTimestampWindow 签名时间戳与服务器时间允许的最大误差
+const DefaultTimestampWindow = 5 * time.Minute
+
+// Server 模拟的火币REST API服务器
+// 按交易所的规则校验v2签名，在内存中保存账户、余额和订单，出错时返回与交易所一致的错误码
+type Server struct {
+	// 服务器地址，例如http://127.0.0.1:12345，可直接作为client.NewClient的endpoint
+	URL string
+
+	server *httptest.Server
+
+	mutex       sync.Mutex
+	now         func() time.Time
+	window      time.Duration
+	keys        map[string]string
+	symbols     map[string]symbol
+	accounts    map[int64]*account
+	orders      map[int64]*order
+	matches     []*match
+	errors      map[string]apiError
+	requests    map[string]int
+	nextAccount int64
+	nextOrder   int64
+	nextMatch   int64
+}
+
+type apiError struct {
+	code    string
+	message string
+}
+
+// newError 创建交易所格式的错误
+func newError(code, format string, args ...interface{}) *apiError {
+	return &apiError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+type symbol struct {
+	symbol          string
+	base            string
+	quote           string
+	pricePrecision  int32
+	amountPrecision int32
+}
+
+type account struct {
+	id        int64
+	accessKey string
+	typ       string
+	trade     map[string]data_type.Decimal
+	frozen    map[string]data_type.Decimal
+}
+
+// NewServer 启动模拟服务器，并添加一组API Key
+func NewServer(accessKeyID, accessKeySecret string) *Server {
+	s := &Server{
+		now:         time.Now,
+		window:      DefaultTimestampWindow,
+		keys:        map[string]string{accessKeyID: accessKeySecret},
+		symbols:     make(map[string]symbol),
+		accounts:    make(map[int64]*account),
+		orders:      make(map[int64]*order),
+		errors:      make(map[string]apiError),
+		requests:    make(map[string]int),
+		nextAccount: 100000,
+		nextOrder:   1000000,
+		nextMatch:   5000000,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// AddKey 添加一组API Key
+func (s *Server) AddKey(accessKeyID, accessKeySecret string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys[accessKeyID] = accessKeySecret
+}
+
+// SetClock 设置服务器时间，用于校验签名时间戳和返回服务器时间，默认为本地时间
+func (s *Server) SetClock(now func() time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.now = now
+}
+
+// SetTimestampWindow 设置签名时间戳与服务器时间允许的最大误差，默认为DefaultTimestampWindow
+func (s *Server) SetTimestampWindow(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.window = d
+}
+
+// AddSymbol 添加交易对，下单时按pricePrecision和amountPrecision检查价格和数量的小数位数
+func (s *Server) AddSymbol(name, base, quote string, pricePrecision, amountPrecision int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.symbols[name] = symbol{
+		symbol:          name,
+		base:            base,
+		quote:           quote,
+		pricePrecision:  int32(pricePrecision),
+		amountPrecision: int32(amountPrecision),
+	}
+}
+
+// AddAccount 为accessKeyID添加账户，accountType例如spot，返回账户ID
+func (s *Server) AddAccount(accessKeyID, accountType string) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextAccount++
+	s.accounts[s.nextAccount] = &account{
+		id:        s.nextAccount,
+		accessKey: accessKeyID,
+		typ:       accountType,
+		trade:     make(map[string]data_type.Decimal),
+		frozen:    make(map[string]data_type.Decimal),
+	}
+	return s.nextAccount
+}
+
+// SetBalance 设置账户中某个币种的可用余额，账户不存在或amount不是有效的数字时panic
+func (s *Server) SetBalance(accountID int64, currency, amount string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a, ok := s.accounts[accountID]
+	if !ok {
+		panic(fmt.Sprintf("clienttest: account %d not found", accountID))
+	}
+	a.trade[currency] = data_type.MustParseDecimal(amount)
+}
+
+// Balance 账户中某个币种的可用余额和冻结余额
+func (s *Server) Balance(accountID int64, currency string) (trade, frozen string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return "0", "0"
+	}
+	return a.trade[currency].Normalize().String(), a.frozen[currency].Normalize().String()
+}
+
+// SetError 设置请求path时返回的错误，code为空时取消
+func (s *Server) SetError(path, code, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if code == "" {
+		delete(s.errors, path)
+		return
+	}
+	s.errors[path] = apiError{code, message}
+}
+
+// Requests 累计收到的path请求数量，包括签名校验失败的请求
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+// handle 处理一个请求
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests[r.URL.Path]++
+
+	var data interface{}
+	var err *apiError
+	if e, ok := s.errors[r.URL.Path]; ok {
+		err = &e
+	} else if strings.HasPrefix(r.URL.Path, "/v1/common/") {
+		data, err = s.handlePublic(r)
+	} else {
+		var accessKey string
+		if accessKey, err = s.verify(r); err == nil {
+			data, err = s.handlePrivate(r, accessKey)
+		}
+	}
+	if err == nil && data == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	var b []byte
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			"status":   "error",
+			"err-code": err.code,
+			"err-msg":  err.message,
+			"data":     nil,
+		})
+	} else {
+		b, _ = json.Marshal(map[string]interface{}{"status": "ok", "data": data})
+	}
+	w.Write(b)
+}
+
+// verify 按交易所的规则校验v2签名，返回请求使用的AccessKeyId
+// 签名内容为请求方法、小写的Host、路径以及除Signature外的所有query参数按参数名排序后URL编码拼接的字符串
+func (s *Server) verify(r *http.Request) (string, *apiError) {
+	query := r.URL.Query()
+	for _, k := range []string{"AccessKeyId", "SignatureMethod", "SignatureVersion", "Timestamp", "Signature"} {
+		if query.Get(k) == "" {
+			return "", newError("api-signature-not-valid", "Signature not valid: Missing required parameter %s [缺少必要参数]", k)
+		}
+	}
+	if query.Get("SignatureMethod") != "HmacSHA256" || query.Get("SignatureVersion") != "2" {
+		return "", newError("api-signature-not-valid", "Signature not valid: Unsupported signature method or version [不支持的签名方法或版本]")
+	}
+	accessKey := query.Get("AccessKeyId")
+	secret, ok := s.keys[accessKey]
+	if !ok {
+		return "", newError("api-signature-not-valid", "Signature not valid: Incorrect Access key [Access key错误]")
+	}
+	ts, err := time.Parse("2006-01-02T15:04:05", query.Get("Timestamp"))
+	if err != nil {
+		return "", newError("api-signature-not-valid", "Signature not valid: Invalid Timestamp [时间戳格式错误]")
+	}
+	if d := s.now().Sub(ts); d > s.window || d < -s.window {
+		return "", newError("api-signature-not-valid", "Signature not valid: Timestamp for this request is outside of the recvWindow [请求时间戳已过期]")
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = url.QueryEscape(k) + "=" + url.QueryEscape(query.Get(k))
+	}
+	payload := r.Method + "\n" + strings.ToLower(r.Host) + "\n" + r.URL.Path + "\n" + strings.Join(lines, "&")
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(payload))
+	expected := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(query.Get("Signature"))) {
+		return "", newError("api-signature-not-valid", "Signature not valid: Verification failure [校验失败]")
+	}
+	return accessKey, nil
+}
+
+// handlePublic 处理不需要签名的接口
+func (s *Server) handlePublic(r *http.Request) (interface{}, *apiError) {
+	switch r.URL.Path {
+	case "/v1/common/timestamp":
+		return s.now().UnixNano() / int64(time.Millisecond), nil
+	case "/v1/common/symbols":
+		names := make([]string, 0, len(s.symbols))
+		for name := range s.symbols {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]map[string]interface{}, len(names))
+		for i, name := range names {
+			sym := s.symbols[name]
+			list[i] = map[string]interface{}{
+				"symbol":           sym.symbol,
+				"base-currency":    sym.base,
+				"quote-currency":   sym.quote,
+				"price-precision":  sym.pricePrecision,
+				"amount-precision": sym.amountPrecision,
+				"value-precision":  8,
+				"symbol-partition": "main",
+				"state":            "online",
+				"api-trading":      "enabled",
+			}
+		}
+		return list, nil
+	case "/v1/common/currencys":
+		set := make(map[string]bool)
+		for _, sym := range s.symbols {
+			set[sym.base] = true
+			set[sym.quote] = true
+		}
+		list := make([]string, 0, len(set))
+		for c := range set {
+			list = append(list, c)
+		}
+		sort.Strings(list)
+		return list, nil
+	}
+	return nil, nil
+}
+
+// handlePrivate 处理需要签名的接口，返回nil, nil表示接口不存在
+func (s *Server) handlePrivate(r *http.Request, accessKey string) (interface{}, *apiError) {
+	path := r.URL.Path
+	query := r.URL.Query()
+	var body json.RawMessage
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, newError("invalid-parameter", "invalid request body: %s", err)
+		}
+	}
+
+	switch {
+	case r.Method == "GET" && path == "/v1/account/accounts":
+		return s.getAccounts(accessKey), nil
+	case r.Method == "GET" && strings.HasPrefix(path, "/v1/account/accounts/") && strings.HasSuffix(path, "/balance"):
+		id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(path, "/v1/account/accounts/"), "/balance"), 10, 64)
+		if err != nil {
+			return nil, newError("invalid-parameter", "invalid account-id")
+		}
+		return s.getBalance(accessKey, id)
+	case r.Method == "POST" && path == "/v1/order/orders/place":
+		var params map[string]string
+		if err := json.Unmarshal(body, &params); err != nil {
+			return nil, newError("invalid-parameter", "invalid request body: %s", err)
+		}
+		o, err := s.placeOrder(accessKey, params)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.FormatInt(o.id, 10), nil
+	case r.Method == "POST" && path == "/v1/order/batch-orders":
+		return s.batchPlaceOrders(accessKey, body)
+	case r.Method == "POST" && path == "/v1/order/orders/batchcancel":
+		return s.batchCancelOrders(accessKey, body)
+	case r.Method == "POST" && path == "/v1/order/orders/batchCancelOpenOrders":
+		return s.cancelOpenOrders(accessKey, body)
+	case r.Method == "GET" && path == "/v1/order/orders/getClientOrder":
+		o := s.findClientOrder(accessKey, query.Get("clientOrderId"))
+		if o == nil {
+			return nil, newError("base-record-invalid", "record invalid")
+		}
+		return o.detail(), nil
+	case r.Method == "GET" && path == "/v1/order/openOrders":
+		return s.getOpenOrders(accessKey, query)
+	case r.Method == "GET" && path == "/v1/order/matchresults":
+		return s.getMatchResults(accessKey, query)
+	case strings.HasPrefix(path, "/v1/order/orders/"):
+		rest := strings.TrimPrefix(path, "/v1/order/orders/")
+		cancel := strings.HasSuffix(rest, "/submitcancel")
+		id, err := strconv.ParseInt(strings.TrimSuffix(rest, "/submitcancel"), 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		if r.Method == "POST" && cancel {
+			if err := s.cancelOrder(accessKey, id); err != nil {
+				return nil, err
+			}
+			return strconv.FormatInt(id, 10), nil
+		}
+		if r.Method == "GET" && !cancel {
+			o := s.findOrder(accessKey, id)
+			if o == nil {
+				return nil, newError("base-record-invalid", "record invalid")
+			}
+			return o.detail(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) getAccounts(accessKey string) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0)
+	for _, a := range s.sortedAccounts() {
+		if a.accessKey == accessKey {
+			list = append(list, map[string]interface{}{
+				"id":      a.id,
+				"type":    a.typ,
+				"subtype": "",
+				"state":   "working",
+			})
+		}
+	}
+	return list
+}
+
+func (s *Server) getBalance(accessKey string, id int64) (interface{}, *apiError) {
+	a, ok := s.accounts[id]
+	if !ok || a.accessKey != accessKey {
+		return nil, newError("account-get-balance-account-inexistent-error", "account for id `%d` and user id does not exist", id)
+	}
+	set := make(map[string]bool)
+	for c := range a.trade {
+		set[c] = true
+	}
+	for c := range a.frozen {
+		set[c] = true
+	}
+	currencies := make([]string, 0, len(set))
+	for c := range set {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	list := make([]map[string]string, 0, len(currencies)*2)
+	for _, c := range currencies {
+		list = append(list,
+			map[string]string{"currency": c, "type": "trade", "balance": format(a.trade[c])},
+			map[string]string{"currency": c, "type": "frozen", "balance": format(a.frozen[c])},
+		)
+	}
+	return map[string]interface{}{
+		"id":    a.id,
+		"type":  a.typ,
+		"state": "working",
+		"list":  list,
+	}, nil
+}
+
+func (s *Server) sortedAccounts() []*account {
+	list := make([]*account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+	return list
+}
+
+// format 按交易所的格式输出18位小数
+func format(d data_type.Decimal) string {
+	return d.Round(18, data_type.RoundDown).String()
+}
+
+func timestamp(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
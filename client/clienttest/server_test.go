@@ -0,0 +1,188 @@
+package clienttest_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/client/clienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func newTestServer(t *testing.T) (*clienttest.Server, *client.Client, int64) {
+	server := clienttest.NewServer("key", "secret")
+	server.AddSymbol("eosusdt", "eos", "usdt", 4, 2)
+	accountID := server.AddAccount("key", client.AccountTypeSpot)
+	server.SetBalance(accountID, "usdt", "100")
+	c, err := client.NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	return server, c, accountID
+}
+
+func TestServer_Signature(t *testing.T) {
+	server, c, accountID := newTestServer(t)
+	defer server.Close()
+
+	accounts, err := c.GetAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []client.Account{{ID: accountID, Type: client.AccountTypeSpot, State: client.AccountStateWorking}}, accounts)
+
+	// 错误的密钥
+	bad, err := client.NewClient(server.URL, "key", "wrong")
+	assert.NoError(t, err)
+	_, err = bad.GetAccounts()
+	assert.True(t, client.IsSignatureInvalid(err))
+	assert.Contains(t, err.(*client.APIError).Message, "Verification failure")
+
+	// 未知的AccessKeyId
+	bad, err = client.NewClient(server.URL, "unknown", "secret")
+	assert.NoError(t, err)
+	_, err = bad.GetAccounts()
+	assert.True(t, client.IsSignatureInvalid(err))
+	assert.Contains(t, err.(*client.APIError).Message, "Incorrect Access key")
+
+	// 时间戳超出允许范围
+	skewed, err := client.NewClient(server.URL, "key", "secret", client.WithClock(fixedClock(time.Now().Add(-10*time.Minute))))
+	assert.NoError(t, err)
+	_, err = skewed.GetAccounts()
+	assert.True(t, client.IsSignatureInvalid(err))
+	server.SetTimestampWindow(time.Hour)
+	_, err = skewed.GetAccounts()
+	assert.NoError(t, err)
+
+	// GET请求的业务参数参与签名
+	_, err = c.GetOpenOrders(client.OpenOrdersRequest{AccountID: accountID, Symbol: "eosusdt", Size: 10})
+	assert.NoError(t, err)
+
+	// 其他用户的账户
+	server.AddKey("other", "secret2")
+	other, err := client.NewClient(server.URL, "other", "secret2")
+	assert.NoError(t, err)
+	_, err = other.GetBalance(accountID)
+	assert.EqualError(t, err, fmt.Sprintf("account for id `%d` and user id does not exist", accountID))
+	assert.Equal(t, 1, server.Requests(fmt.Sprintf("/v1/account/accounts/%d/balance", accountID)))
+}
+
+func TestServer_Orders(t *testing.T) {
+	server, c, accountID := newTestServer(t)
+	defer server.Close()
+
+	// 精度和余额检查
+	_, err := c.PlaceOrder(client.PlaceOrderRequest{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeBuyLimit, Amount: "10.123", Price: "5"})
+	assert.Equal(t, "order-orderamount-precision-error", err.(*client.APIError).Code)
+	_, err = c.PlaceOrder(client.PlaceOrderRequest{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeBuyLimit, Amount: "30", Price: "5"})
+	assert.True(t, client.IsInsufficientBalance(err))
+	_, err = c.PlaceOrder(client.PlaceOrderRequest{AccountID: accountID, Symbol: "btcusdt", Type: client.OrderTypeBuyLimit, Amount: "1", Price: "5"})
+	assert.Equal(t, "base-symbol-error", err.(*client.APIError).Code)
+
+	id, err := c.PlaceOrder(client.PlaceOrderRequest{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeBuyLimit, Amount: "10", Price: "5", ClientOrderID: "a1"})
+	assert.NoError(t, err)
+	trade, frozen := server.Balance(accountID, "usdt")
+	assert.Equal(t, "50", trade)
+	assert.Equal(t, "50", frozen)
+
+	// 部分成交，成交价格低于委托价格的部分退回可用余额
+	assert.NoError(t, server.Fill(id, "4.5", "4"))
+	assert.Error(t, server.Fill(id, "5.1", "1"))
+	assert.Error(t, server.Fill(id, "5", "7"))
+	order, err := c.GetOrderByClientOrderID("a1")
+	assert.NoError(t, err)
+	assert.Equal(t, id, order.ID)
+	assert.Equal(t, client.OrderStatePartialFilled, order.State)
+	assert.Equal(t, "4", order.FilledAmount.Normalize().String())
+	assert.Equal(t, "18", order.FilledCashAmount.Normalize().String())
+	trade, frozen = server.Balance(accountID, "usdt")
+	assert.Equal(t, "52", trade)
+	assert.Equal(t, "30", frozen)
+	trade, _ = server.Balance(accountID, "eos")
+	assert.Equal(t, "4", trade)
+
+	open, err := c.GetOpenOrders(client.OpenOrdersRequest{AccountID: accountID})
+	assert.NoError(t, err)
+	assert.Len(t, open, 1)
+	assert.Equal(t, "4", open[0].FilledAmount.Normalize().String())
+
+	results, err := c.GetMatchResults(client.MatchResultsRequest{Symbol: "eosusdt"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, id, results[0].OrderID)
+	assert.Equal(t, "4.5", results[0].Price.Normalize().String())
+
+	// 撤单后解冻未成交部分
+	assert.NoError(t, c.CancelOrder(id))
+	err = c.CancelOrder(id)
+	assert.Equal(t, "order-orderstate-error", err.(*client.APIError).Code)
+	order, err = c.GetOrder(id)
+	assert.NoError(t, err)
+	assert.Equal(t, client.OrderStatePartialCanceled, order.State)
+	trade, frozen = server.Balance(accountID, "usdt")
+	assert.Equal(t, "82", trade)
+	assert.Equal(t, "0", frozen)
+
+	_, err = c.GetOrder(1)
+	assert.True(t, client.IsOrderNotFound(err))
+}
+
+func TestServer_Batch(t *testing.T) {
+	server, c, accountID := newTestServer(t)
+	defer server.Close()
+
+	results, err := c.BatchPlaceOrders([]client.PlaceOrderRequest{
+		{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeBuyLimit, Amount: "1", Price: "1"},
+		{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeSellLimit, Amount: "1", Price: "9"},
+		{AccountID: accountID, Symbol: "eosusdt", Type: client.OrderTypeBuyLimit, Amount: "2", Price: "1"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.True(t, results[0].Success())
+	assert.Equal(t, "account-frozen-balance-insufficient-error", results[1].ErrCode)
+	assert.True(t, results[2].Success())
+
+	cancel, err := c.BatchCancelOrders([]int64{results[0].OrderID, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{results[0].OrderID}, cancel.Success)
+	assert.Equal(t, []client.BatchCancelFailure{{OrderID: 1, OrderState: -1, ErrCode: "base-record-invalid", ErrMsg: "record invalid"}}, cancel.Failed)
+
+	ret, err := c.CancelOpenOrders(client.CancelOpenOrdersRequest{AccountID: accountID, Symbol: "eosusdt"})
+	assert.NoError(t, err)
+	assert.Equal(t, &client.CancelOpenOrdersResult{SuccessCount: 1, NextID: -1}, ret)
+	trade, frozen := server.Balance(accountID, "usdt")
+	assert.Equal(t, "100", trade)
+	assert.Equal(t, "0", frozen)
+}
+
+func TestServer_Public(t *testing.T) {
+	server, c, _ := newTestServer(t)
+	defer server.Close()
+	server.AddSymbol("btcusdt", "btc", "usdt", 2, 6)
+
+	_, err := c.Request("GET", "/v1/unknown", nil)
+	assert.Equal(t, http.StatusNotFound, err.(*client.APIError).HTTPStatus)
+
+	now := time.Unix(1500000000, 0)
+	server.SetClock(func() time.Time { return now })
+
+	symbols, err := c.GetSymbols()
+	assert.NoError(t, err)
+	assert.Len(t, symbols, 2)
+	assert.Equal(t, "btcusdt", symbols[0].Symbol)
+	assert.Equal(t, 6, symbols[0].AmountPrecision)
+	currencies, err := c.GetCurrencies()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"btc", "eos", "usdt"}, currencies)
+	ts, err := c.GetServerTime()
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(ts))
+
+	server.SetError("/v1/common/symbols", "too-many-request", "rate limited")
+	_, err = c.GetSymbols()
+	assert.True(t, client.IsRateLimited(err))
+}
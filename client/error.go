@@ -0,0 +1,92 @@
+package client
+
+import (
+	"net/http"
+)
+
+/// 接口返回的错误，status为error或HTTP状态码不为2xx时返回
+type APIError struct {
+	// 错误码，即返回结果中的err-code
+	Code string
+	// 错误信息，即返回结果中的err-msg
+	Message string
+	// HTTP状态码，WebSocket接口返回的错误为0
+	HTTPStatus int
+	// 请求路径，WebSocket接口为订阅或请求的主题
+	Path string
+	// 原始返回内容
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Code != "" {
+		return e.Code
+	}
+	return http.StatusText(e.HTTPStatus)
+}
+
+/// 余额不足相关的错误码
+var insufficientBalanceCodes = []string{
+	"account-balance-insufficient-error",
+	"account-frozen-balance-insufficient-error",
+	"order-accountbalance-error",
+	"insufficient-balance",
+}
+
+/// 订单不存在相关的错误码
+var orderNotFoundCodes = []string{
+	"base-record-invalid",
+	"order-queryorder-invalid",
+	"order-not-found",
+}
+
+/// 请求频率超限相关的错误码
+var rateLimitedCodes = []string{
+	"too-many-request",
+	"api-limit-exceeded",
+}
+
+/// 签名错误相关的错误码
+var signatureInvalidCodes = []string{
+	"api-signature-not-valid",
+	"api-signature-check-failed",
+}
+
+func hasErrorCode(err error, codes []string) bool {
+	e, ok := err.(*APIError)
+	if !ok {
+		return false
+	}
+	for _, c := range codes {
+		if e.Code == c {
+			return true
+		}
+	}
+	return false
+}
+
+/// 是否为余额不足错误
+func IsInsufficientBalance(err error) bool {
+	return hasErrorCode(err, insufficientBalanceCodes)
+}
+
+/// 是否为订单不存在错误
+func IsOrderNotFound(err error) bool {
+	return hasErrorCode(err, orderNotFoundCodes)
+}
+
+/// 是否为请求频率超限错误
+func IsRateLimited(err error) bool {
+	if e, ok := err.(*APIError); ok && e.HTTPStatus == http.StatusTooManyRequests {
+		return true
+	}
+	return hasErrorCode(err, rateLimitedCodes)
+}
+
+/// 是否为签名错误
+func IsSignatureInvalid(err error) bool {
+	return hasErrorCode(err, signatureInvalidCodes)
+}
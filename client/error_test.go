@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	var status int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+
+	status = http.StatusOK
+	body = `{"status":"error","err-code":"account-balance-insufficient-error","err-msg":"balance insufficient","data":null}`
+	_, err = client.PlaceOrder(PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyMarket, Amount: "10"})
+	assert.EqualError(t, err, "balance insufficient")
+	e, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, "account-balance-insufficient-error", e.Code)
+	assert.Equal(t, http.StatusOK, e.HTTPStatus)
+	assert.Equal(t, "/v1/order/orders/place", e.Path)
+	assert.Equal(t, body, string(e.Body))
+	assert.True(t, IsInsufficientBalance(err))
+	assert.False(t, IsOrderNotFound(err))
+
+	body = `{"status":"error","err-code":"base-record-invalid","err-msg":"record invalid","data":null}`
+	_, err = client.GetOrder(1)
+	assert.True(t, IsOrderNotFound(err))
+
+	body = `{"status":"error","err-code":"api-signature-not-valid","err-msg":"Signature not valid","data":null}`
+	_, err = client.GetAccounts()
+	assert.True(t, IsSignatureInvalid(err))
+	assert.False(t, IsRateLimited(err))
+
+	status = http.StatusTooManyRequests
+	body = `too many requests`
+	_, err = client.GetAccounts()
+	assert.True(t, IsRateLimited(err))
+	assert.EqualError(t, err, "Too Many Requests")
+	assert.Equal(t, http.StatusTooManyRequests, err.(*APIError).HTTPStatus)
+
+	assert.False(t, IsRateLimited(fmt.Errorf("too-many-request")))
+	assert.False(t, IsRateLimited(nil))
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"strconv"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+/// K线周期
+const (
+	KlinePeriod1Min  = "1min"
+	KlinePeriod5Min  = "5min"
+	KlinePeriod15Min = "15min"
+	KlinePeriod30Min = "30min"
+	KlinePeriod60Min = "60min"
+	KlinePeriod4Hour = "4hour"
+	KlinePeriod1Day  = "1day"
+	KlinePeriod1Week = "1week"
+	KlinePeriod1Mon  = "1mon"
+	KlinePeriod1Year = "1year"
+)
+
+/// 查询K线，size为0时使用服务器默认值
+func (c *Client) GetKlines(symbol, period string, size int) ([]data_type.KlineTick, error) {
+	data := ParamData{"symbol": symbol, "period": period}
+	if size > 0 {
+		data["size"] = strconv.Itoa(size)
+	}
+	var ret []data_type.KlineTick
+	if err := c.requestData("GET", "/market/history/kline", data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询深度，step为合并深度类型，例如step0
+func (c *Client) GetDepth(symbol, step string) (*data_type.Depth, error) {
+	var ret = &data_type.Depth{}
+	if err := c.requestResult("GET", "/market/depth", ParamData{"symbol": symbol, "type": step}, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询最近成交记录，size为0时使用服务器默认值
+func (c *Client) GetTrades(symbol string, size int) ([]data_type.TradeItem, error) {
+	data := ParamData{"symbol": symbol}
+	if size > 0 {
+		data["size"] = strconv.Itoa(size)
+	}
+	var list []struct {
+		Data []data_type.TradeItem `json:"data"`
+	}
+	if err := c.requestData("GET", "/market/history/trade", data, &list); err != nil {
+		return nil, err
+	}
+	var ret []data_type.TradeItem
+	for _, v := range list {
+		ret = append(ret, v.Data...)
+	}
+	return ret, nil
+}
+
+/// 查询指定交易对的聚合行情
+func (c *Client) GetTicker(symbol string) (*data_type.Ticker, error) {
+	detail, err := c.GetMergedDetail(symbol)
+	if err != nil {
+		return nil, err
+	}
+	tick := detail.Tick
+	ret := &data_type.Ticker{
+		Symbol: symbol,
+		Open:   tick.Open,
+		High:   tick.High,
+		Low:    tick.Low,
+		Close:  tick.Close,
+		Amount: tick.Amount,
+		Vol:    tick.Vol,
+		Count:  tick.Count,
+	}
+	if len(tick.Bid) >= 2 {
+		ret.Bid, ret.BidSize = tick.Bid[0], tick.Bid[1]
+	}
+	if len(tick.Ask) >= 2 {
+		ret.Ask, ret.AskSize = tick.Ask[0], tick.Ask[1]
+	}
+	return ret, nil
+}
+
+/// 查询所有交易对的聚合行情
+func (c *Client) GetAllTickers() ([]data_type.Ticker, error) {
+	var ret []data_type.Ticker
+	if err := c.requestData("GET", "/market/tickers", nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询聚合行情，包含最优买卖价
+func (c *Client) GetMergedDetail(symbol string) (*data_type.MergedDetail, error) {
+	var ret = &data_type.MergedDetail{}
+	if err := c.requestResult("GET", "/market/detail/merged", ParamData{"symbol": symbol}, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询最近24小时成交量等统计数据
+func (c *Client) GetMarketDetail(symbol string) (*data_type.MarketDetail, error) {
+	var ret = &data_type.MarketDetail{}
+	if err := c.requestResult("GET", "/market/detail", ParamData{"symbol": symbol}, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
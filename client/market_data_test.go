@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMarketServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/market/history/kline":
+			assert.Equal(t, "1min", q.Get("period"))
+			assert.Equal(t, "2", q.Get("size"))
+			w.Write([]byte(`{"status":"ok","ch":"market.eosusdt.kline.1min","ts":1516870811119,"data":[{"amount":280.4836,"close":14.29,"count":9,"high":14.3,"id":1516870800,"low":14.29,"open":14.29,"vol":4010.253191},{"amount":10,"close":14.3,"count":1,"high":14.3,"id":1516870740,"low":14.3,"open":14.3,"vol":143}]}`))
+		case "/market/depth":
+			assert.Equal(t, "step0", q.Get("type"))
+			w.Write([]byte(`{"status":"ok","ch":"market.eosusdt.depth.step0","ts":1516870811119,"tick":{"bids":[[14.29,21.5757]],"asks":[[14.3,1193.5857]],"ts":1516870810023,"version":1557850542}}`))
+		case "/market/history/trade":
+			w.Write([]byte(`{"status":"ok","ch":"market.eosusdt.trade.detail","ts":1516870811119,"data":[{"id":1557850942,"ts":1516870810708,"data":[{"amount":8.3334,"direction":"sell","id":17592232489498,"price":14.29,"ts":1516870810708},{"amount":1,"direction":"buy","id":17592232489499,"price":14.3,"ts":1516870810708}]},{"id":1557850941,"ts":1516870810000,"data":[{"amount":2,"direction":"buy","id":17592232489400,"price":14.3,"ts":1516870810000}]}]}`))
+		case "/market/detail/merged":
+			w.Write([]byte(`{"status":"ok","ch":"market.eosusdt.detail.merged","ts":1516870811119,"tick":{"amount":1207461.4473,"open":13.43,"close":14.29,"high":14.56,"id":1557850542,"count":21640,"low":13.22,"version":1557850542,"ask":[14.3,1193.5857],"vol":16866489.57354,"bid":[14.29,21.5757]}}`))
+		case "/market/tickers":
+			w.Write([]byte(`{"status":"ok","ts":1516870811119,"data":[{"symbol":"eosusdt","open":13.43,"high":14.56,"low":13.22,"close":14.29,"amount":1207461.4473,"vol":16866489.57354,"count":21640,"bid":14.29,"bidSize":21.5757,"ask":14.3,"askSize":1193.5857}]}`))
+		case "/market/detail":
+			w.Write([]byte(`{"status":"ok","ch":"market.eosusdt.detail","ts":1516870811119,"tick":{"amount":1207461.4473,"open":13.43,"close":14.29,"high":14.56,"id":1557850542,"count":21640,"low":13.22,"version":1557850542,"vol":16866489.57354}}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestClient_MarketData(t *testing.T) {
+	server := newTestMarketServer(t)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, "", "")
+	assert.NoError(t, err)
+
+	klines, err := c.GetKlines("eosusdt", KlinePeriod1Min, 2)
+	assert.NoError(t, err)
+	assert.Len(t, klines, 2)
+	assert.Equal(t, data_type.KlineTick{ID: 1516870800, Amount: 280.4836, Count: 9, Open: 14.29, Close: 14.29, Low: 14.29, High: 14.3, Vol: 4010.253191}, klines[0])
+
+	depth, err := c.GetDepth("eosusdt", "step0")
+	assert.NoError(t, err)
+	assert.Equal(t, "market.eosusdt.depth.step0", depth.Ch)
+	assert.Equal(t, []float64{14.29, 21.5757}, depth.Tick.Bids[0])
+	assert.Equal(t, []float64{14.3, 1193.5857}, depth.Tick.Asks[0])
+
+	trades, err := c.GetTrades("eosusdt", 2)
+	assert.NoError(t, err)
+	assert.Len(t, trades, 3)
+	assert.Equal(t, "sell", trades[0].Direction)
+	assert.Equal(t, uint(17592232489400), trades[2].ID)
+
+	ticker, err := c.GetTicker("eosusdt")
+	assert.NoError(t, err)
+	assert.Equal(t, &data_type.Ticker{Symbol: "eosusdt", Open: 13.43, High: 14.56, Low: 13.22, Close: 14.29, Amount: 1207461.4473, Vol: 16866489.57354, Count: 21640, Bid: 14.29, BidSize: 21.5757, Ask: 14.3, AskSize: 1193.5857}, ticker)
+
+	tickers, err := c.GetAllTickers()
+	assert.NoError(t, err)
+	assert.Equal(t, []data_type.Ticker{*ticker}, tickers)
+
+	merged, err := c.GetMergedDetail("eosusdt")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1557850542), merged.Tick.Version)
+
+	detail, err := c.GetMarketDetail("eosusdt")
+	assert.NoError(t, err)
+	assert.Equal(t, "market.eosusdt.detail", detail.Ch)
+	assert.Equal(t, 14.56, detail.Tick.High)
+}
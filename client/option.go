@@ -0,0 +1,165 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/// 默认的User-Agent
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/39.0.2171.71 Safari/537.36"
+
+/// 发送请求使用的HTTP配置
+type httpConfig struct {
+	client    *http.Client
+	userAgent string
+	header    http.Header
+	// 收到响应后调用，用于读取限频信息等响应头
+	afterResponse func(res *http.Response)
+}
+
+var defaultHTTPConfig = &httpConfig{client: http.DefaultClient, userAgent: DefaultUserAgent}
+
+/// 创建客户端的选项
+type Option func(o *options)
+
+type options struct {
+	httpClient *http.Client
+	proxy      string
+	tlsConfig  *tls.Config
+	timeout    time.Duration
+	userAgent  string
+	header     http.Header
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+	clock      Clock
+	validator  *OrderValidator
+}
+
+/// 使用指定的http.Client发送请求，不能与WithProxy、WithTLSConfig、WithTimeout同时使用
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+/// 通过代理发送请求，支持http、https和socks5协议，例如 socks5://127.0.0.1:1080
+func WithProxy(proxyURL string) Option {
+	return func(o *options) {
+		o.proxy = proxyURL
+	}
+}
+
+/// 使用指定的TLS配置，例如自定义根证书
+func WithTLSConfig(c *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = c
+	}
+}
+
+/// 设置请求超时时间
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+/// 设置User-Agent，默认为DefaultUserAgent
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+/// 添加请求头，可以覆盖默认的请求头
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+/// 请求失败时按指定策略重试，默认不重试
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = &p
+	}
+}
+
+/// 使用限频器限制请求频率，默认不限制
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+/// 使用指定的时钟生成签名时间戳，通常为与服务器同步的TimeSync
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+/// 下单前使用校验器检查订单，不符合交易对规则时不发送请求
+func WithOrderValidator(v *OrderValidator) Option {
+	return func(o *options) {
+		o.validator = v
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{userAgent: DefaultUserAgent}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/// 根据选项生成HTTP配置
+func newHTTPConfig(o *options) (*httpConfig, error) {
+	conf := &httpConfig{client: http.DefaultClient, userAgent: o.userAgent, header: o.header}
+
+	if o.proxy == "" && o.tlsConfig == nil && o.timeout == 0 {
+		if o.httpClient != nil {
+			conf.client = o.httpClient
+		}
+		return conf, nil
+	}
+	if o.httpClient != nil {
+		return nil, fmt.Errorf("WithHTTPClient cannot be used together with WithProxy, WithTLSConfig or WithTimeout")
+	}
+
+	transport := newTransport()
+	if o.proxy != "" {
+		proxyURL, err := url.Parse(o.proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if o.tlsConfig != nil {
+		transport.TLSClientConfig = o.tlsConfig
+	}
+	conf.client = &http.Client{Transport: transport, Timeout: o.timeout}
+	return conf, nil
+}
+
+/// 创建与http.DefaultTransport配置相同的Transport
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient_Options(t *testing.T) {
+	var transportUsed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-agent/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "en-us", r.Header.Get("Accept-Language"))
+		assert.Equal(t, "abc", r.Header.Get("X-Trace-Id"))
+		w.Write([]byte(`{"status":"ok","data":[]}`))
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		transportUsed = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	client, err := NewClient(server.URL, "key", "secret",
+		WithHTTPClient(httpClient),
+		WithUserAgent("my-agent/1.0"),
+		WithHeader("Accept-Language", "en-us"),
+		WithHeader("X-Trace-Id", "abc"),
+	)
+	assert.NoError(t, err)
+	_, err = client.GetAccounts()
+	assert.NoError(t, err)
+	assert.True(t, transportUsed)
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.Host
+		w.Write([]byte(`{"status":"ok","data":[]}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient("http://api.huobi.example", "key", "secret", WithProxy(proxy.URL), WithTimeout(time.Second))
+	assert.NoError(t, err)
+	_, err = client.GetAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, "api.huobi.example", proxied)
+	assert.Equal(t, time.Second, client.http.client.Timeout)
+
+	_, err = NewClient(Endpoint, "key", "secret", WithProxy(proxy.URL), WithHTTPClient(http.DefaultClient))
+	assert.Error(t, err)
+	_, err = NewClient(Endpoint, "key", "secret", WithProxy("://bad"))
+	assert.IsType(t, &url.Error{}, err)
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
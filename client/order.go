@@ -0,0 +1,323 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+/// 订单类型
+type OrderType string
+
+const (
+	OrderTypeBuyMarket      OrderType = "buy-market"
+	OrderTypeSellMarket     OrderType = "sell-market"
+	OrderTypeBuyLimit       OrderType = "buy-limit"
+	OrderTypeSellLimit      OrderType = "sell-limit"
+	OrderTypeBuyIoc         OrderType = "buy-ioc"
+	OrderTypeSellIoc        OrderType = "sell-ioc"
+	OrderTypeBuyLimitMaker  OrderType = "buy-limit-maker"
+	OrderTypeSellLimitMaker OrderType = "sell-limit-maker"
+)
+
+/// 是否为有效的订单类型
+func (t OrderType) Valid() bool {
+	switch t {
+	case OrderTypeBuyMarket, OrderTypeSellMarket,
+		OrderTypeBuyLimit, OrderTypeSellLimit,
+		OrderTypeBuyIoc, OrderTypeSellIoc,
+		OrderTypeBuyLimitMaker, OrderTypeSellLimitMaker:
+		return true
+	}
+	return false
+}
+
+/// 是否为市价单，市价单不需要指定价格
+func (t OrderType) IsMarket() bool {
+	return t == OrderTypeBuyMarket || t == OrderTypeSellMarket
+}
+
+/// 订单方向，buy或sell
+func (t OrderType) Side() string {
+	if strings.HasPrefix(string(t), OrderSideBuy+"-") {
+		return OrderSideBuy
+	}
+	return OrderSideSell
+}
+
+/// 订单状态
+type OrderState string
+
+const (
+	OrderStateSubmitting      OrderState = "submitting"
+	OrderStateSubmitted       OrderState = "submitted"
+	OrderStatePartialFilled   OrderState = "partial-filled"
+	OrderStatePartialCanceled OrderState = "partial-canceled"
+	OrderStateFilled          OrderState = "filled"
+	OrderStateCanceled        OrderState = "canceled"
+)
+
+/// 订单方向
+const (
+	OrderSideBuy  = "buy"
+	OrderSideSell = "sell"
+)
+
+/// 下单请求
+/// 价格和数量使用字符串，以免浮点数转换丢失精度
+type PlaceOrderRequest struct {
+	AccountID     int64
+	Symbol        string
+	Type          OrderType
+	Amount        string
+	Price         string
+	Source        string
+	ClientOrderID string
+}
+
+/// 检查下单参数
+func (r *PlaceOrderRequest) Validate() error {
+	if r.AccountID <= 0 {
+		return fmt.Errorf("invalid account-id: %d", r.AccountID)
+	}
+	if r.Symbol == "" {
+		return fmt.Errorf("missing symbol")
+	}
+	if !r.Type.Valid() {
+		return fmt.Errorf("invalid order type: %q", r.Type)
+	}
+	if r.Amount == "" {
+		return fmt.Errorf("missing amount")
+	}
+	if r.Type.IsMarket() {
+		if r.Price != "" {
+			return fmt.Errorf("price is not allowed for %s order", r.Type)
+		}
+	} else if r.Price == "" {
+		return fmt.Errorf("missing price for %s order", r.Type)
+	}
+	return nil
+}
+
+/// 转换为请求参数
+func (r *PlaceOrderRequest) params() ParamData {
+	data := ParamData{
+		"account-id": strconv.FormatInt(r.AccountID, 10),
+		"symbol":     r.Symbol,
+		"type":       string(r.Type),
+		"amount":     r.Amount,
+	}
+	if r.Price != "" {
+		data["price"] = r.Price
+	}
+	if r.Source != "" {
+		data["source"] = r.Source
+	}
+	if r.ClientOrderID != "" {
+		data["client-order-id"] = r.ClientOrderID
+	}
+	return data
+}
+
+/// 订单详情
+/// 价格、数量和手续费使用Decimal，以免浮点数转换丢失精度
+type Order struct {
+	ID               int64             `json:"id"`
+	Symbol           string            `json:"symbol"`
+	AccountID        int64             `json:"account-id"`
+	ClientOrderID    string            `json:"client-order-id"`
+	Type             OrderType         `json:"type"`
+	State            OrderState        `json:"state"`
+	Source           string            `json:"source"`
+	Price            data_type.Decimal `json:"price"`
+	Amount           data_type.Decimal `json:"amount"`
+	FilledAmount     data_type.Decimal `json:"field-amount"`
+	FilledCashAmount data_type.Decimal `json:"field-cash-amount"`
+	FilledFees       data_type.Decimal `json:"field-fees"`
+	CreatedAt        int64             `json:"created-at"`
+	CanceledAt       int64             `json:"canceled-at"`
+	FinishedAt       int64             `json:"finished-at"`
+}
+
+/// 未成交订单接口返回的成交字段名与订单详情不同
+type openOrder struct {
+	Order
+	OpenFilledAmount     data_type.Decimal `json:"filled-amount"`
+	OpenFilledCashAmount data_type.Decimal `json:"filled-cash-amount"`
+	OpenFilledFees       data_type.Decimal `json:"filled-fees"`
+}
+
+/// 查询未成交订单的条件
+type OpenOrdersRequest struct {
+	AccountID int64
+	Symbol    string
+	Side      string
+	Size      int
+}
+
+/// 查询成交明细的条件
+type MatchResultsRequest struct {
+	Symbol    string
+	Types     []OrderType
+	StartDate string
+	EndDate   string
+	From      int64
+	Direct    string
+	Size      int
+}
+
+/// 成交明细
+/// 价格、数量和手续费使用Decimal，以免浮点数转换丢失精度
+type MatchResult struct {
+	ID           int64             `json:"id"`
+	OrderID      int64             `json:"order-id"`
+	MatchID      int64             `json:"match-id"`
+	Symbol       string            `json:"symbol"`
+	Type         OrderType         `json:"type"`
+	Source       string            `json:"source"`
+	Role         string            `json:"role"`
+	Price        data_type.Decimal `json:"price"`
+	FilledAmount data_type.Decimal `json:"filled-amount"`
+	FilledFees   data_type.Decimal `json:"filled-fees"`
+	FeeCurrency  string            `json:"fee-currency"`
+	CreatedAt    int64             `json:"created-at"`
+}
+
+func orderPath(orderID int64) string {
+	return "/v1/order/orders/" + strconv.FormatInt(orderID, 10)
+}
+
+/// 下单，返回订单ID
+/// 设置了重试策略时，会自动生成client-order-id以保证重试不会重复下单
+func (c *Client) PlaceOrder(req PlaceOrderRequest) (int64, error) {
+	if err := c.validateOrder(&req); err != nil {
+		return 0, err
+	}
+	if c.retry != nil {
+		return c.placeOrderWithRetry(req)
+	}
+	return c.placeOrder(req)
+}
+
+/// 检查下单参数，设置了订单校验器时同时检查交易对规则
+func (c *Client) validateOrder(req *PlaceOrderRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if c.validator != nil {
+		return c.validator.Validate(req)
+	}
+	return nil
+}
+
+/// 发送一次下单请求
+func (c *Client) placeOrder(req PlaceOrderRequest) (int64, error) {
+	ret, err := c.sendOnce("POST", "/v1/order/orders/place", req.params(), nil)
+	if err != nil {
+		return 0, err
+	}
+	var id string
+	if err := decodeData(ret, &id); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+/// 撤销订单
+func (c *Client) CancelOrder(orderID int64) error {
+	var id string
+	return c.requestData("POST", orderPath(orderID)+"/submitcancel", nil, &id)
+}
+
+/// 查询订单详情
+func (c *Client) GetOrder(orderID int64) (*Order, error) {
+	var ret = &Order{}
+	if err := c.requestData("GET", orderPath(orderID), nil, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 按client-order-id查询订单详情
+func (c *Client) GetOrderByClientOrderID(clientOrderID string) (*Order, error) {
+	var ret = &Order{}
+	data := ParamData{"clientOrderId": clientOrderID}
+	if err := c.requestData("GET", "/v1/order/orders/getClientOrder", data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询未成交订单
+func (c *Client) GetOpenOrders(req OpenOrdersRequest) ([]Order, error) {
+	data := ParamData{}
+	if req.AccountID > 0 {
+		data["account-id"] = strconv.FormatInt(req.AccountID, 10)
+	}
+	if req.Symbol != "" {
+		data["symbol"] = req.Symbol
+	}
+	if req.Side != "" {
+		if req.Side != OrderSideBuy && req.Side != OrderSideSell {
+			return nil, fmt.Errorf("invalid order side: %q", req.Side)
+		}
+		data["side"] = req.Side
+	}
+	if req.Size > 0 {
+		data["size"] = strconv.Itoa(req.Size)
+	}
+
+	var list []openOrder
+	if err := c.requestData("GET", "/v1/order/openOrders", data, &list); err != nil {
+		return nil, err
+	}
+	ret := make([]Order, len(list))
+	for i, o := range list {
+		ret[i] = o.Order
+		ret[i].FilledAmount = o.OpenFilledAmount
+		ret[i].FilledCashAmount = o.OpenFilledCashAmount
+		ret[i].FilledFees = o.OpenFilledFees
+	}
+	return ret, nil
+}
+
+/// 查询当前成交明细
+func (c *Client) GetMatchResults(req MatchResultsRequest) ([]MatchResult, error) {
+	if req.Symbol == "" {
+		return nil, fmt.Errorf("missing symbol")
+	}
+	data := ParamData{"symbol": req.Symbol}
+	if len(req.Types) > 0 {
+		types := make([]string, len(req.Types))
+		for i, t := range req.Types {
+			if !t.Valid() {
+				return nil, fmt.Errorf("invalid order type: %q", t)
+			}
+			types[i] = string(t)
+		}
+		data["types"] = strings.Join(types, ",")
+	}
+	if req.StartDate != "" {
+		data["start-date"] = req.StartDate
+	}
+	if req.EndDate != "" {
+		data["end-date"] = req.EndDate
+	}
+	if req.From > 0 {
+		data["from"] = strconv.FormatInt(req.From, 10)
+	}
+	if req.Direct != "" {
+		data["direct"] = req.Direct
+	}
+	if req.Size > 0 {
+		data["size"] = strconv.Itoa(req.Size)
+	}
+
+	var ret []MatchResult
+	if err := c.requestData("GET", "/v1/order/matchresults", data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderType(t *testing.T) {
+	assert.True(t, OrderTypeBuyLimit.Valid())
+	assert.True(t, OrderTypeSellLimitMaker.Valid())
+	assert.False(t, OrderType("buy_limit").Valid())
+	assert.True(t, OrderTypeSellMarket.IsMarket())
+	assert.False(t, OrderTypeBuyIoc.IsMarket())
+	assert.Equal(t, OrderSideBuy, OrderTypeBuyLimitMaker.Side())
+	assert.Equal(t, OrderSideSell, OrderTypeSellIoc.Side())
+}
+
+func TestPlaceOrderRequest_Validate(t *testing.T) {
+	req := PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1", Price: "14.29"}
+	assert.NoError(t, req.Validate())
+
+	req.Type = "buy-limt"
+	assert.EqualError(t, req.Validate(), `invalid order type: "buy-limt"`)
+
+	req.Type = OrderTypeSellLimit
+	req.Price = ""
+	assert.EqualError(t, req.Validate(), "missing price for sell-limit order")
+
+	req.Type = OrderTypeBuyMarket
+	assert.NoError(t, req.Validate())
+	req.Price = "1"
+	assert.EqualError(t, req.Validate(), "price is not allowed for buy-market order")
+
+	req = PlaceOrderRequest{Symbol: "eosusdt", Type: OrderTypeBuyMarket, Amount: "1"}
+	assert.EqualError(t, req.Validate(), "invalid account-id: 0")
+}
+
+func TestClient_PlaceOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/order/orders/place", r.URL.Path)
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(b, &body))
+		assert.Equal(t, map[string]string{
+			"account-id":      "100009",
+			"symbol":          "eosusdt",
+			"type":            "buy-limit",
+			"amount":          "10.1",
+			"price":           "14.29",
+			"client-order-id": "a0001",
+		}, body)
+		w.Write([]byte(`{"status":"ok","data":"59378"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	id, err := client.PlaceOrder(PlaceOrderRequest{
+		AccountID:     100009,
+		Symbol:        "eosusdt",
+		Type:          OrderTypeBuyLimit,
+		Amount:        "10.1",
+		Price:         "14.29",
+		ClientOrderID: "a0001",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(59378), id)
+
+	_, err = client.PlaceOrder(PlaceOrderRequest{AccountID: 100009, Symbol: "eosusdt", Type: "buy", Amount: "1"})
+	assert.Error(t, err)
+}
+
+func TestClient_GetOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/order/orders/59378":
+			w.Write([]byte(`{"status":"ok","data":{"id":59378,"symbol":"eosusdt","account-id":100009,"amount":"10.1000000000","price":"14.2900000000","created-at":1494901162595,"type":"buy-limit","field-amount":"10.1000000000","field-cash-amount":"144.3290000000","field-fees":"0.0202000000","finished-at":1494901400468,"source":"api","state":"filled","canceled-at":0}}`))
+		case "/v1/order/orders/59378/submitcancel":
+			assert.Equal(t, "POST", r.Method)
+			w.Write([]byte(`{"status":"ok","data":"59378"}`))
+		case "/v1/order/openOrders":
+			assert.Equal(t, "eosusdt", r.URL.Query().Get("symbol"))
+			assert.Equal(t, "sell", r.URL.Query().Get("side"))
+			w.Write([]byte(`{"status":"ok","data":[{"id":5454937,"symbol":"eosusdt","account-id":100009,"amount":"1.000000000000000000","price":"15.000000000000000000","created-at":1533784580810,"type":"sell-limit","filled-amount":"0.4","filled-cash-amount":"6.0","filled-fees":"0.012","source":"api","state":"partial-filled"}]}`))
+		case "/v1/order/matchresults":
+			assert.Equal(t, "buy-limit,sell-limit", r.URL.Query().Get("types"))
+			w.Write([]byte(`{"status":"ok","data":[{"id":29553,"order-id":59378,"match-id":59335,"symbol":"eosusdt","type":"buy-limit","source":"api","price":"14.2900000000","filled-amount":"10.1000000000","filled-fees":"0.0202000000","fee-currency":"eos","role":"taker","created-at":1494901400435}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+
+	order, err := client.GetOrder(59378)
+	assert.NoError(t, err)
+	assert.Equal(t, OrderTypeBuyLimit, order.Type)
+	assert.Equal(t, OrderStateFilled, order.State)
+	assert.Equal(t, "14.2900000000", order.Price.String())
+	assert.Equal(t, "10.1000000000", order.FilledAmount.String())
+	assert.Equal(t, "144.3290000000", order.FilledCashAmount.String())
+	assert.Equal(t, "0.0202000000", order.FilledFees.String())
+
+	assert.NoError(t, client.CancelOrder(59378))
+
+	orders, err := client.GetOpenOrders(OpenOrdersRequest{Symbol: "eosusdt", Side: OrderSideSell})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, OrderStatePartialFilled, orders[0].State)
+	assert.Equal(t, "0.4", orders[0].FilledAmount.String())
+	assert.Equal(t, "6.0", orders[0].FilledCashAmount.String())
+	assert.Equal(t, "0.012", orders[0].FilledFees.String())
+
+	_, err = client.GetOpenOrders(OpenOrdersRequest{Side: "both"})
+	assert.EqualError(t, err, `invalid order side: "both"`)
+
+	results, err := client.GetMatchResults(MatchResultsRequest{Symbol: "eosusdt", Types: []OrderType{OrderTypeBuyLimit, OrderTypeSellLimit}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, int64(59378), results[0].OrderID)
+	assert.Equal(t, "taker", results[0].Role)
+	assert.Equal(t, "14.2900000000", results[0].Price.String())
+	assert.Equal(t, "10.1000000000", results[0].FilledAmount.String())
+
+	_, err = client.GetMatchResults(MatchResultsRequest{Symbol: "eosusdt", Types: []OrderType{"buy"}})
+	assert.EqualError(t, err, `invalid order type: "buy"`)
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/// 超过限频且RateLimiter设置为FailFast时返回的错误
+var RateLimitExceededError = fmt.Errorf("client-side rate limit exceeded")
+
+/// 接口分组，不同分组的限频相互独立
+type EndpointGroup string
+
+const (
+	// 公开行情及参考数据接口
+	EndpointGroupMarket EndpointGroup = "market"
+	// 需要签名的账户及查询接口
+	EndpointGroupAccount EndpointGroup = "account"
+	// 下单及撤单接口
+	EndpointGroupOrder EndpointGroup = "order"
+)
+
+/// 根据请求方法和路径判断接口分组
+func GetEndpointGroup(method, path string) EndpointGroup {
+	if strings.HasPrefix(path, "/market/") || strings.HasPrefix(path, "/v1/common/") {
+		return EndpointGroupMarket
+	}
+	if !isGetMethod(strings.ToUpper(method)) && strings.HasPrefix(path, "/v1/order/") {
+		return EndpointGroupOrder
+	}
+	return EndpointGroupAccount
+}
+
+/// 限频规则，每秒生成Rate个令牌，最多积累Burst个
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+/// 默认限频规则，比火币网公布的限制略低
+var DefaultRateLimits = map[EndpointGroup]RateLimit{
+	EndpointGroupMarket:  {Rate: 10, Burst: 10},
+	EndpointGroupAccount: {Rate: 8, Burst: 8},
+	EndpointGroupOrder:   {Rate: 8, Burst: 8},
+}
+
+/// 服务器返回的剩余请求次数
+const rateLimitRemainHeader = "X-HB-RateLimit-Requests-Remain"
+
+/// 服务器返回的限频重置时间（毫秒时间戳）
+const rateLimitExpireHeader = "X-HB-RateLimit-Requests-Expire"
+
+type tokenBucket struct {
+	limit        RateLimit
+	tokens       float64
+	updatedAt    time.Time
+	blockedUntil time.Time
+}
+
+/// 按经过的时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updatedAt).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.updatedAt = now
+}
+
+/// 尝试取一个令牌，失败时返回需要等待的时间
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.limit.Rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+/// 客户端限频器，按接口分组和AccessKeyId分别使用令牌桶限制请求频率
+/// 多个Client可以共用同一个RateLimiter
+type RateLimiter struct {
+	// 为true时超过限频立即返回RateLimitExceededError，否则阻塞等待；应在开始使用前设置
+	FailFast bool
+
+	mutex   sync.Mutex
+	limits  map[EndpointGroup]RateLimit
+	buckets map[string]*tokenBucket
+}
+
+/// 创建限频器，limits为nil时使用DefaultRateLimits，未配置的分组不限频
+func NewRateLimiter(limits map[EndpointGroup]RateLimit) *RateLimiter {
+	if limits == nil {
+		limits = DefaultRateLimits
+	}
+	l := &RateLimiter{
+		limits:  make(map[EndpointGroup]RateLimit),
+		buckets: make(map[string]*tokenBucket),
+	}
+	for k, v := range limits {
+		l.limits[k] = v
+	}
+	return l
+}
+
+/// 取得令牌桶，未配置限频规则时返回nil
+func (l *RateLimiter) bucket(group EndpointGroup, accessKeyId string, now time.Time) *tokenBucket {
+	limit, ok := l.limits[group]
+	if !ok {
+		return nil
+	}
+	key := string(group) + "|" + accessKeyId
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+/// 等待直到可以发送请求，FailFast为true时不等待而是直接返回RateLimitExceededError
+func (l *RateLimiter) Wait(ctx context.Context, group EndpointGroup, accessKeyId string) error {
+	for {
+		l.mutex.Lock()
+		var wait time.Duration
+		if b := l.bucket(group, accessKeyId, time.Now()); b != nil {
+			wait = b.take(time.Now())
+		}
+		l.mutex.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+		if l.FailFast {
+			return RateLimitExceededError
+		}
+		if err := SleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+/// 根据服务器返回的剩余次数调整令牌数量，remain为0时在resetAt之前暂停请求
+func (l *RateLimiter) Update(group EndpointGroup, accessKeyId string, remain int, resetAt time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	b := l.bucket(group, accessKeyId, now)
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	if float64(remain) < b.tokens {
+		b.tokens = float64(remain)
+	}
+	if remain <= 0 && resetAt.After(now) {
+		b.blockedUntil = resetAt
+	}
+}
+
+/// 从响应头读取服务器返回的限频信息
+func (l *RateLimiter) updateFromResponse(res *http.Response, accessKeyId string) {
+	remainStr := res.Header.Get(rateLimitRemainHeader)
+	if remainStr == "" {
+		return
+	}
+	remain, err := strconv.Atoi(remainStr)
+	if err != nil {
+		return
+	}
+	var resetAt time.Time
+	if expire, err := strconv.ParseInt(res.Header.Get(rateLimitExpireHeader), 10, 64); err == nil {
+		resetAt = time.Unix(0, expire*int64(time.Millisecond))
+	}
+	l.Update(GetEndpointGroup(res.Request.Method, res.Request.URL.Path), accessKeyId, remain, resetAt)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEndpointGroup(t *testing.T) {
+	assert.Equal(t, EndpointGroupMarket, GetEndpointGroup("GET", "/market/history/kline"))
+	assert.Equal(t, EndpointGroupMarket, GetEndpointGroup("GET", "/v1/common/symbols"))
+	assert.Equal(t, EndpointGroupAccount, GetEndpointGroup("GET", "/v1/account/accounts"))
+	assert.Equal(t, EndpointGroupAccount, GetEndpointGroup("GET", "/v1/order/openOrders"))
+	assert.Equal(t, EndpointGroupOrder, GetEndpointGroup("post", "/v1/order/orders/place"))
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := NewRateLimiter(map[EndpointGroup]RateLimit{EndpointGroupOrder: {Rate: 20, Burst: 2}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, l.Wait(ctx, EndpointGroupOrder, "key1"))
+	}
+	// 前2次使用积累的令牌，之后每次需要等待50毫秒
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 90*time.Millisecond, elapsed)
+
+	// 不同AccessKeyId及未配置的分组互不影响
+	assert.NoError(t, l.Wait(ctx, EndpointGroupOrder, "key2"))
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, l.Wait(ctx, EndpointGroupMarket, "key1"))
+	}
+
+	l.FailFast = true
+	assert.NoError(t, l.Wait(ctx, EndpointGroupOrder, "key2"))
+	assert.Equal(t, RateLimitExceededError, l.Wait(ctx, EndpointGroupOrder, "key2"))
+
+	l.FailFast = false
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	l.Update(EndpointGroupOrder, "key2", 0, time.Now().Add(time.Hour))
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, EndpointGroupOrder, "key2"))
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// 服务器告知剩余次数为0，200毫秒后重置
+		w.Header().Set("X-HB-RateLimit-Requests-Remain", "0")
+		w.Header().Set("X-HB-RateLimit-Requests-Expire", strconv.FormatInt(time.Now().Add(200*time.Millisecond).UnixNano()/int64(time.Millisecond), 10))
+		w.Write([]byte(`{"status":"ok","data":[]}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(nil)
+	limiter.FailFast = true
+	client, err := NewClient(server.URL, "key", "secret", WithRateLimiter(limiter))
+	assert.NoError(t, err)
+	_, err = client.GetAccounts()
+	assert.NoError(t, err)
+	_, err = client.GetAccounts()
+	assert.Equal(t, RateLimitExceededError, err)
+	assert.Equal(t, 1, requests)
+
+	// 其他AccessKeyId不受影响
+	other, err := NewClient(server.URL, "key2", "secret", WithRateLimiter(limiter))
+	assert.NoError(t, err)
+	_, err = other.GetAccounts()
+	assert.NoError(t, err)
+
+	limiter.FailFast = false
+	start := time.Now()
+	_, err = client.GetAccounts()
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.Equal(t, 3, requests)
+}
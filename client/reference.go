@@ -0,0 +1,184 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/debug"
+)
+
+/// 交易对状态
+const (
+	SymbolStateOnline    = "online"
+	SymbolStateOffline   = "offline"
+	SymbolStateSuspend   = "suspend"
+	SymbolStatePreOnline = "pre-online"
+)
+
+/// 交易对信息
+type Symbol struct {
+	Symbol                 string            `json:"symbol"`
+	BaseCurrency           string            `json:"base-currency"`
+	QuoteCurrency          string            `json:"quote-currency"`
+	PricePrecision         int               `json:"price-precision"`
+	AmountPrecision        int               `json:"amount-precision"`
+	ValuePrecision         int               `json:"value-precision"`
+	SymbolPartition        string            `json:"symbol-partition"`
+	State                  string            `json:"state"`
+	APITrading             string            `json:"api-trading"`
+	MinOrderAmt            data_type.Decimal `json:"min-order-amt"`
+	MaxOrderAmt            data_type.Decimal `json:"max-order-amt"`
+	MinOrderValue          data_type.Decimal `json:"min-order-value"`
+	LimitOrderMinOrderAmt  data_type.Decimal `json:"limit-order-min-order-amt"`
+	LimitOrderMaxOrderAmt  data_type.Decimal `json:"limit-order-max-order-amt"`
+	SellMarketMinOrderAmt  data_type.Decimal `json:"sell-market-min-order-amt"`
+	SellMarketMaxOrderAmt  data_type.Decimal `json:"sell-market-max-order-amt"`
+	BuyMarketMaxOrderValue data_type.Decimal `json:"buy-market-max-order-value"`
+}
+
+/// 查询所有交易对信息
+func (c *Client) GetSymbols() ([]Symbol, error) {
+	var ret []Symbol
+	if err := c.requestData("GET", "/v1/common/symbols", nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 查询所有币种
+func (c *Client) GetCurrencies() ([]string, error) {
+	var ret []string
+	if err := c.requestData("GET", "/v1/common/currencys", nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/// 交易对信息缓存
+type SymbolRegistry struct {
+	// 调用Start()后自动刷新的间隔，默认1小时
+	Interval time.Duration
+
+	client    *Client
+	mutex     sync.RWMutex
+	symbols   map[string]Symbol
+	list      []Symbol
+	updatedAt time.Time
+	stop      chan struct{}
+}
+
+/// 创建交易对信息缓存，使用c查询交易对信息
+func NewSymbolRegistry(c *Client) *SymbolRegistry {
+	return &SymbolRegistry{
+		Interval: time.Hour,
+		client:   c,
+		symbols:  make(map[string]Symbol),
+	}
+}
+
+/// 立即从服务器刷新交易对信息
+func (r *SymbolRegistry) Refresh() error {
+	list, err := r.client.GetSymbols()
+	if err != nil {
+		return err
+	}
+	r.Set(list)
+	return nil
+}
+
+/// 替换缓存的交易对信息
+func (r *SymbolRegistry) Set(list []Symbol) {
+	symbols := make(map[string]Symbol, len(list))
+	sorted := make([]Symbol, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Symbol < sorted[j].Symbol })
+	for _, s := range sorted {
+		symbols[s.Symbol] = s
+	}
+
+	r.mutex.Lock()
+	r.symbols = symbols
+	r.list = sorted
+	r.updatedAt = time.Now()
+	r.mutex.Unlock()
+}
+
+/// 取指定交易对的信息
+func (r *SymbolRegistry) Get(symbol string) (Symbol, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	s, ok := r.symbols[symbol]
+	return s, ok
+}
+
+/// 取所有交易对信息，按交易对名称排序
+func (r *SymbolRegistry) All() []Symbol {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ret := make([]Symbol, len(r.list))
+	copy(ret, r.list)
+	return ret
+}
+
+/// 上次刷新的时间，未刷新过时为零值
+func (r *SymbolRegistry) UpdatedAt() time.Time {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.updatedAt
+}
+
+/// 为所有在线交易对生成订阅主题，format中的%s替换为交易对名称
+/// 例如：registry.Topics("market.%s.kline.1min")
+func (r *SymbolRegistry) Topics(format string) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var ret []string
+	for _, s := range r.list {
+		if s.State == SymbolStateOnline {
+			ret = append(ret, fmt.Sprintf(format, s.Symbol))
+		}
+	}
+	return ret
+}
+
+/// 立即刷新一次，并在后台按Interval定期刷新
+func (r *SymbolRegistry) Start() error {
+	err := r.Refresh()
+	r.mutex.Lock()
+	if r.stop != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	stop := make(chan struct{})
+	r.stop = stop
+	r.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Refresh(); err != nil {
+					debug.Println("refresh symbols failed", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return err
+}
+
+/// 停止后台刷新
+func (r *SymbolRegistry) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSymbolsResponse = `{"status":"ok","data":[
+	{"base-currency":"eos","quote-currency":"usdt","price-precision":4,"amount-precision":2,"symbol-partition":"main","symbol":"eosusdt","state":"online","value-precision":8,"min-order-amt":0.1,"max-order-amt":100000,"min-order-value":5,"limit-order-min-order-amt":0.1,"limit-order-max-order-amt":100000,"sell-market-min-order-amt":0.1,"sell-market-max-order-amt":10000,"buy-market-max-order-value":100000,"api-trading":"enabled"},
+	{"base-currency":"btc","quote-currency":"usdt","price-precision":2,"amount-precision":6,"symbol-partition":"main","symbol":"btcusdt","state":"online","value-precision":8,"min-order-amt":0.0001,"max-order-amt":1000,"min-order-value":5,"api-trading":"enabled"},
+	{"base-currency":"xyz","quote-currency":"btc","price-precision":8,"amount-precision":2,"symbol-partition":"innovation","symbol":"xyzbtc","state":"offline","value-precision":8,"min-order-amt":1,"max-order-amt":100000,"min-order-value":0.0001,"api-trading":"disabled"}
+]}`
+
+func TestClient_GetSymbols(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/common/symbols":
+			w.Write([]byte(testSymbolsResponse))
+		case "/v1/common/currencys":
+			w.Write([]byte(`{"status":"ok","data":["usdt","btc","eos"]}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, "", "")
+	assert.NoError(t, err)
+	symbols, err := c.GetSymbols()
+	assert.NoError(t, err)
+	assert.Len(t, symbols, 3)
+	assert.Equal(t, "eosusdt", symbols[0].Symbol)
+	assert.Equal(t, 4, symbols[0].PricePrecision)
+	assert.Equal(t, 2, symbols[0].AmountPrecision)
+	assert.Equal(t, "0.1", symbols[0].MinOrderAmt.String())
+	assert.Equal(t, "5", symbols[0].MinOrderValue.String())
+	assert.Equal(t, "0.0001", symbols[2].MinOrderValue.String())
+
+	currencies, err := c.GetCurrencies()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"usdt", "btc", "eos"}, currencies)
+}
+
+func TestSymbolRegistry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(testSymbolsResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, "", "")
+	assert.NoError(t, err)
+	r := NewSymbolRegistry(c)
+	assert.True(t, r.UpdatedAt().IsZero())
+	_, ok := r.Get("eosusdt")
+	assert.False(t, ok)
+
+	r.Interval = 10 * time.Millisecond
+	assert.NoError(t, r.Start())
+	defer r.Stop()
+	s, ok := r.Get("btcusdt")
+	assert.True(t, ok)
+	assert.Equal(t, 6, s.AmountPrecision)
+	assert.False(t, r.UpdatedAt().IsZero())
+
+	all := r.All()
+	assert.Equal(t, []string{"btcusdt", "eosusdt", "xyzbtc"}, []string{all[0].Symbol, all[1].Symbol, all[2].Symbol})
+	assert.Equal(t, []string{"market.btcusdt.kline.1min", "market.eosusdt.kline.1min"}, r.Topics("market.%s.kline.1min"))
+
+	time.Sleep(50 * time.Millisecond)
+	r.Stop()
+	assert.True(t, atomic.LoadInt32(&requests) > 1)
+}
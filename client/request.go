@@ -2,12 +2,11 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/bitly/go-simplejson"
 )
@@ -24,13 +23,26 @@ type ParamData = map[string]string
 
 /// 发送原始请求
 func SendRequest(sign *Sign, method, scheme, host, path string, data ParamData) (*simplejson.Json, error) {
+	return sendRequest(context.Background(), defaultHTTPConfig, sign, method, scheme, host, path, data, nil)
+}
+
+/// 发送原始请求，ctx取消或超时时中断请求
+func SendRequestWithContext(ctx context.Context, sign *Sign, method, scheme, host, path string, data ParamData) (*simplejson.Json, error) {
+	return sendRequest(ctx, defaultHTTPConfig, sign, method, scheme, host, path, data, nil)
+}
+
+/// 发送请求，POST请求时如果jsonBody不为nil则使用其作为请求内容，否则使用data
+func sendRequest(ctx context.Context, conf *httpConfig, sign *Sign, method, scheme, host, path string, data ParamData, jsonBody interface{}) (*simplejson.Json, error) {
 	var body *bytes.Buffer
 	method = strings.ToUpper(method)
 	if data == nil {
 		data = ParamData{}
 	}
+	if jsonBody == nil {
+		jsonBody = data
+	}
 
-	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05")
+	timestamp := sign.Timestamp()
 	// 参与计算签名的参数
 	signData := make(map[string]string)
 	if isGetMethod(method) {
@@ -44,10 +56,11 @@ func SendRequest(sign *Sign, method, scheme, host, path string, data ParamData)
 	} else {
 		signData["Signature"] = s
 	}
+	var apiPath = path
 	path += "?" + encodeQueryString(signData)
 	if isGetMethod(method) == false {
 		// POST 请求 JSON
-		if b, err := json.Marshal(data); err != nil {
+		if b, err := json.Marshal(jsonBody); err != nil {
 			return nil, err
 		} else {
 			body = bytes.NewBuffer(b)
@@ -64,18 +77,26 @@ func SendRequest(sign *Sign, method, scheme, host, path string, data ParamData)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("User-Agent", "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/39.0.2171.71 Safari/537.36")
+	req = req.WithContext(ctx)
+	req.Header.Add("User-Agent", conf.userAgent)
 	req.Header.Add("Accept-Language", "zh-cn")
+	for k, v := range conf.header {
+		req.Header[k] = v
+	}
 	if isGetMethod(method) {
-		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	} else {
-		req.Header.Add("Content-Type", "application/json")
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := conf.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
+	if conf.afterResponse != nil {
+		conf.afterResponse(res)
+	}
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
@@ -83,11 +104,20 @@ func SendRequest(sign *Sign, method, scheme, host, path string, data ParamData)
 
 	json, err := simplejson.NewJson(resBody)
 	if err != nil {
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return nil, &APIError{HTTPStatus: res.StatusCode, Path: apiPath, Body: resBody}
+		}
 		return nil, err
 	}
 	var status = json.Get("status").MustString()
-	if status == "error" {
-		return json, fmt.Errorf(json.Get("err-msg").MustString())
+	if status == "error" || res.StatusCode < 200 || res.StatusCode >= 300 {
+		return json, &APIError{
+			Code:       json.Get("err-code").MustString(),
+			Message:    json.Get("err-msg").MustString(),
+			HTTPStatus: res.StatusCode,
+			Path:       apiPath,
+			Body:       resBody,
+		}
 	}
 	return json, nil
 }
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/debug"
+)
+
+/// 请求失败后的重试策略
+type RetryPolicy struct {
+	// 最大尝试次数（包含首次请求），小于等于1表示不重试
+	MaxAttempts int
+	// 首次重试前的等待时间
+	InitialBackoff time.Duration
+	// 最大等待时间
+	MaxBackoff time.Duration
+	// 每次重试等待时间的增长倍数
+	Multiplier float64
+	// 随机抖动比例，取值0~1，例如0.2表示在等待时间上下浮动20%
+	Jitter float64
+}
+
+/// 默认重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+/// 第attempt次请求失败后需要等待的时间，attempt从1开始
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (mathrand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+/// 是否为可以重试的错误：网络错误、服务器5xx错误及请求频率超限
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if e, ok := err.(*APIError); ok {
+		return e.HTTPStatus >= 500 || IsRateLimited(err)
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+/// 是否无法确定请求是否已被服务器处理：网络错误或服务器5xx错误
+func isAmbiguous(err error) bool {
+	return IsRetryable(err) && !IsRateLimited(err)
+}
+
+/// 等待指定时间，ctx取消或超时时提前返回ctx.Err()
+func SleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/// 生成随机的client-order-id
+func newClientOrderID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		for i := range b {
+			b[i] = byte(mathrand.Intn(256))
+		}
+	}
+	return hex.EncodeToString(b)
+}
+
+/// 发送请求，根据重试策略重试失败的请求
+/// GET请求在可重试的错误时重试；其他请求可能已被服务器执行，仅在请求频率超限时重试
+func (c *Client) send(method, path string, data ParamData, body interface{}) (*simplejson.Json, error) {
+	ctx := c.Context()
+	for attempt := 1; ; attempt++ {
+		ret, err := c.sendOnce(method, path, data, body)
+		if err == nil || !c.shouldRetry(ctx, method, err, attempt) {
+			return ret, err
+		}
+		debug.Println("retry", method, path, attempt, err)
+		if err := SleepContext(ctx, c.retry.Backoff(attempt)); err != nil {
+			return ret, err
+		}
+	}
+}
+
+func (c *Client) shouldRetry(ctx context.Context, method string, err error, attempt int) bool {
+	if c.retry == nil || attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if isGetMethod(strings.ToUpper(method)) {
+		return IsRetryable(err)
+	}
+	return IsRateLimited(err)
+}
+
+/// 下单遇到无法确定结果的错误，且按client-order-id也无法确认订单是否存在时返回的错误
+/// 调用方可以稍后按ClientOrderID查询订单，确认结果后再决定是否重新下单
+type PlaceOrderError struct {
+	// 下单使用的client-order-id
+	ClientOrderID string
+	// 最后一次下单返回的错误
+	Err error
+	// 按client-order-id查询订单返回的错误
+	LookupErr error
+}
+
+func (e *PlaceOrderError) Error() string {
+	return fmt.Sprintf("place order %s: %s (lookup: %s)", e.ClientOrderID, e.Err, e.LookupErr)
+}
+
+/// 下单并在失败时安全地重试
+/// 自动生成client-order-id，遇到无法确定结果的错误时先按client-order-id查询订单，确认未下单后才重新提交
+/// 最后一次尝试失败时同样会先查询，无法确认是否已下单时返回*PlaceOrderError
+func (c *Client) placeOrderWithRetry(req PlaceOrderRequest) (int64, error) {
+	ctx := c.Context()
+	if req.ClientOrderID == "" {
+		req.ClientOrderID = newClientOrderID()
+	}
+	for attempt := 1; ; attempt++ {
+		id, err := c.placeOrder(req)
+		if err == nil {
+			return id, nil
+		}
+		if isAmbiguous(err) {
+			order, lookupErr := c.GetOrderByClientOrderID(req.ClientOrderID)
+			if lookupErr == nil {
+				debug.Println("order placed before retry", req.ClientOrderID, order.ID)
+				return order.ID, nil
+			}
+			if !IsOrderNotFound(lookupErr) {
+				return 0, &PlaceOrderError{ClientOrderID: req.ClientOrderID, Err: err, LookupErr: lookupErr}
+			}
+		} else if !IsRateLimited(err) {
+			return 0, err
+		}
+		if attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+			return 0, err
+		}
+		debug.Println("retry place order", req.ClientOrderID, attempt, err)
+		if err := SleepContext(ctx, c.retry.Backoff(attempt)); err != nil {
+			return 0, err
+		}
+	}
+}
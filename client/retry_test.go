@@ -0,0 +1,206 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Multiplier: 2}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond, d)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&APIError{HTTPStatus: 502}))
+	assert.True(t, IsRetryable(&APIError{HTTPStatus: 200, Code: "too-many-request"}))
+	assert.False(t, IsRetryable(&APIError{HTTPStatus: 200, Code: "api-signature-not-valid"}))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestClient_RetryGet(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"status":"ok","data":[{"id":1,"type":"spot","state":"working"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy))
+	assert.NoError(t, err)
+	accounts, err := client.GetAccounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, 3, requests)
+
+	// 超过最大尝试次数
+	requests = -10
+	_, err = client.GetAccounts()
+	assert.Equal(t, http.StatusBadGateway, err.(*APIError).HTTPStatus)
+	assert.Equal(t, -7, requests)
+
+	// 不设置重试策略时不重试
+	client, err = NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	requests = 0
+	_, err = client.GetAccounts()
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_RetryPost(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy))
+	assert.NoError(t, err)
+	err = client.CancelOrder(1)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_PlaceOrderRetry(t *testing.T) {
+	var placed []string
+	var lookups int
+	var orderExists bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/order/orders/place":
+			b, _ := ioutil.ReadAll(r.Body)
+			var body map[string]string
+			json.Unmarshal(b, &body)
+			placed = append(placed, body["client-order-id"])
+			if len(placed) == 1 {
+				// 服务器已下单但返回了网关错误
+				orderExists = r.Header.Get("X-Test-Placed") != ""
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			w.Write([]byte(`{"status":"ok","data":"200"}`))
+		case "/v1/order/orders/getClientOrder":
+			lookups++
+			assert.Equal(t, placed[0], r.URL.Query().Get("clientOrderId"))
+			if orderExists {
+				w.Write([]byte(`{"status":"ok","data":{"id":100,"state":"submitted","price":"1","amount":"1","field-amount":"0","field-cash-amount":"0","field-fees":"0"}}`))
+			} else {
+				w.Write([]byte(`{"status":"error","err-code":"base-record-invalid","err-msg":"record invalid","data":null}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	req := PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1", Price: "1"}
+
+	// 订单不存在，重新提交
+	client, err := NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy))
+	assert.NoError(t, err)
+	id, err := client.PlaceOrder(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200), id)
+	assert.Len(t, placed, 2)
+	assert.NotEmpty(t, placed[0])
+	assert.Equal(t, placed[0], placed[1])
+	assert.Equal(t, 1, lookups)
+
+	// 订单已存在，不重新提交
+	placed = nil
+	lookups = 0
+	client, err = NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy), WithHeader("X-Test-Placed", "1"))
+	assert.NoError(t, err)
+	id, err = client.PlaceOrder(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), id)
+	assert.Len(t, placed, 1)
+	assert.Equal(t, 1, lookups)
+}
+
+func TestClient_PlaceOrderRetry_Timeout(t *testing.T) {
+	var mutex sync.Mutex
+	var placed []string
+	lookupFails := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/v1/order/orders/place":
+			// 服务器已下单但响应超时
+			b, _ := ioutil.ReadAll(r.Body)
+			var body map[string]string
+			json.Unmarshal(b, &body)
+			placed = append(placed, body["client-order-id"])
+			mutex.Unlock()
+			time.Sleep(100 * time.Millisecond)
+			mutex.Lock()
+		case "/v1/order/orders/getClientOrder":
+			if lookupFails {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			assert.Equal(t, placed[0], r.URL.Query().Get("clientOrderId"))
+			w.Write([]byte(`{"status":"ok","data":{"id":100,"state":"submitted","price":"1","amount":"1","field-amount":"0","field-cash-amount":"0","field-fees":"0"}}`))
+		}
+	}))
+	defer server.Close()
+
+	req := PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1", Price: "1"}
+	for _, attempts := range []int{1, 3} {
+		policy := testRetryPolicy
+		policy.MaxAttempts = attempts
+		mutex.Lock()
+		placed = nil
+		mutex.Unlock()
+
+		// 最后一次尝试超时后仍按client-order-id查询，订单已存在时返回订单ID
+		client, err := NewClient(server.URL, "key", "secret", WithRetryPolicy(policy), WithTimeout(20*time.Millisecond))
+		assert.NoError(t, err)
+		id, err := client.PlaceOrder(req)
+		assert.NoError(t, err, attempts)
+		assert.Equal(t, int64(100), id, attempts)
+		mutex.Lock()
+		assert.Len(t, placed, 1, attempts)
+		mutex.Unlock()
+	}
+
+	// 查询也失败时返回带有client-order-id的错误，不再重新提交
+	mutex.Lock()
+	placed = nil
+	lookupFails = true
+	mutex.Unlock()
+	client, err := NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy), WithTimeout(20*time.Millisecond))
+	assert.NoError(t, err)
+	_, err = client.PlaceOrder(req)
+	e, ok := err.(*PlaceOrderError)
+	if assert.True(t, ok, err) {
+		mutex.Lock()
+		assert.Len(t, placed, 1)
+		assert.Equal(t, placed[0], e.ClientOrderID)
+		mutex.Unlock()
+		assert.True(t, IsRetryable(e.Err))
+		assert.Equal(t, http.StatusBadGateway, e.LookupErr.(*APIError).HTTPStatus)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 )
 
 func getMapKeys(m map[string]string) (keys []string) {
@@ -45,6 +46,8 @@ type Sign struct {
 	AccessKeySecret  string
 	SignatureMethod  string
 	SignatureVersion string
+	// 生成签名时间戳使用的时钟，为nil时使用本地时间，可设置为TimeSync以避免本地时间偏差
+	Clock Clock
 }
 
 func NewSign(accessKeyId, accessKeySecret string) *Sign {
@@ -56,6 +59,17 @@ func NewSign(accessKeyId, accessKeySecret string) *Sign {
 	}
 }
 
+/// 生成签名使用的时间戳
+func (s *Sign) Timestamp() string {
+	var now time.Time
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	} else {
+		now = time.Now()
+	}
+	return now.UTC().Format("2006-01-02T15:04:05")
+}
+
 func (s *Sign) Get(method, host, path, timestamp string, params map[string]string) (string, error) {
 	var str = method + "\n" + host + "\n" + path + "\n"
 	params["AccessKeyId"] = s.AccessKeyId
@@ -65,3 +79,32 @@ func (s *Sign) Get(method, host, path, timestamp string, params map[string]strin
 	str += encodeQueryString(params)
 	return computeHmac256(str, s.AccessKeySecret), nil
 }
+
+/// WebSocket v2鉴权参数，使用2.1版签名
+type WebSocketAuthParams struct {
+	AuthType         string `json:"authType"`
+	AccessKey        string `json:"accessKey"`
+	SignatureMethod  string `json:"signatureMethod"`
+	SignatureVersion string `json:"signatureVersion"`
+	Timestamp        string `json:"timestamp"`
+	Signature        string `json:"signature"`
+}
+
+/// 生成WebSocket v2鉴权参数，host和path为WebSocket入口的主机名和路径，例如api.huobi.pro和/ws/v2
+func (s *Sign) WebSocketAuth(host, path, timestamp string) *WebSocketAuthParams {
+	params := map[string]string{
+		"accessKey":        s.AccessKeyId,
+		"signatureMethod":  s.SignatureMethod,
+		"signatureVersion": "2.1",
+		"timestamp":        timestamp,
+	}
+	str := "GET\n" + host + "\n" + path + "\n" + encodeQueryString(params)
+	return &WebSocketAuthParams{
+		AuthType:         "api",
+		AccessKey:        s.AccessKeyId,
+		SignatureMethod:  s.SignatureMethod,
+		SignatureVersion: "2.1",
+		Timestamp:        timestamp,
+		Signature:        computeHmac256(str, s.AccessKeySecret),
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leizongmin/huobiapi/debug"
+)
+
+/// 时钟，用于生成签名时间戳等
+type Clock interface {
+	Now() time.Time
+}
+
+type localClock struct{}
+
+func (localClock) Now() time.Time {
+	return time.Now()
+}
+
+/// 本地时钟
+var LocalClock Clock = localClock{}
+
+/// 查询服务器当前时间
+func (c *Client) GetServerTime() (time.Time, error) {
+	var ms int64
+	if err := c.requestData("GET", "/v1/common/timestamp", nil, &ms); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+/// 与服务器同步的时钟
+/// 同步后基于本地单调时钟推算服务器时间，不受本地系统时间被修改的影响
+type TimeSync struct {
+	// 调用Start()后自动同步的间隔，默认10分钟
+	Interval time.Duration
+
+	client     *Client
+	mutex      sync.RWMutex
+	synced     bool
+	serverTime time.Time
+	localTime  time.Time
+	stop       chan struct{}
+}
+
+/// 创建服务器时间同步器，使用c查询服务器时间
+func NewTimeSync(c *Client) *TimeSync {
+	return &TimeSync{Interval: 10 * time.Minute, client: c}
+}
+
+/// 立即与服务器同步一次
+func (t *TimeSync) Sync() error {
+	start := time.Now()
+	serverTime, err := t.client.GetServerTime()
+	if err != nil {
+		return err
+	}
+	// 以请求往返的中间时刻作为服务器返回时间对应的本地时间
+	localTime := start.Add(time.Since(start) / 2)
+
+	t.mutex.Lock()
+	t.synced = true
+	t.serverTime = serverTime
+	t.localTime = localTime
+	t.mutex.Unlock()
+	debug.Println("time synced", serverTime.Sub(localTime))
+	return nil
+}
+
+/// 当前服务器时间，未同步时返回本地时间
+func (t *TimeSync) Now() time.Time {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if !t.synced {
+		return time.Now()
+	}
+	return t.serverTime.Add(time.Since(t.localTime))
+}
+
+/// 服务器时间与本地时间的差值
+func (t *TimeSync) Offset() time.Duration {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if !t.synced {
+		return 0
+	}
+	return t.serverTime.Sub(t.localTime.Round(0))
+}
+
+/// 是否已经同步过
+func (t *TimeSync) Synced() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.synced
+}
+
+/// 立即同步一次，并在后台按Interval定期同步
+func (t *TimeSync) Start() error {
+	err := t.Sync()
+	t.mutex.Lock()
+	if t.stop != nil {
+		t.mutex.Unlock()
+		return err
+	}
+	stop := make(chan struct{})
+	t.stop = stop
+	t.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(t.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.Sync(); err != nil {
+					debug.Println("time sync failed", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return err
+}
+
+/// 停止后台同步
+func (t *TimeSync) Stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
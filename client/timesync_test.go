@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeSync(t *testing.T) {
+	// 服务器时间比本地快1小时
+	var timestamps []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/common/timestamp":
+			fmt.Fprintf(w, `{"status":"ok","data":%d}`, time.Now().Add(time.Hour).UnixNano()/int64(time.Millisecond))
+		default:
+			timestamps = append(timestamps, r.URL.Query().Get("Timestamp"))
+			w.Write([]byte(`{"status":"ok","data":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, "key", "secret")
+	assert.NoError(t, err)
+	ts := NewTimeSync(c)
+	assert.False(t, ts.Synced())
+	assert.Equal(t, time.Duration(0), ts.Offset())
+
+	ts.Interval = 10 * time.Millisecond
+	assert.NoError(t, ts.Start())
+	defer ts.Stop()
+	assert.True(t, ts.Synced())
+	assert.InDelta(t, float64(time.Hour), float64(ts.Offset()), float64(time.Second))
+	assert.InDelta(t, float64(time.Hour), float64(ts.Now().Sub(time.Now())), float64(time.Second))
+
+	// 使用同步后的时钟生成签名时间戳
+	c, err = NewClient(server.URL, "key", "secret", WithClock(ts))
+	assert.NoError(t, err)
+	_, err = c.GetAccounts()
+	assert.NoError(t, err)
+	signed, err := time.Parse("2006-01-02T15:04:05", timestamps[0])
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(time.Hour), float64(signed.Sub(time.Now())), float64(2*time.Second))
+
+	time.Sleep(30 * time.Millisecond)
+	ts.Stop()
+	ts.Stop()
+}
+
+func TestSign_Timestamp(t *testing.T) {
+	sign := NewSign("key", "secret")
+	sign.Clock = fixedClock(time.Date(2017, 5, 11, 15, 19, 30, 0, time.FixedZone("CST", 8*3600)))
+	assert.Equal(t, "2017-05-11T07:19:30", sign.Timestamp())
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
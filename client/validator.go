@@ -0,0 +1,135 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+/// 订单不符合交易对规则时返回的错误
+type OrderValidationError struct {
+	Symbol string
+	Field  string
+	Reason string
+}
+
+func (e *OrderValidationError) Error() string {
+	return fmt.Sprintf("invalid %s for %s: %s", e.Field, e.Symbol, e.Reason)
+}
+
+/// 下单前根据交易对规则检查订单，可选地将价格和数量按精度截断
+type OrderValidator struct {
+	Registry *SymbolRegistry
+	// 为true时将超出精度的价格和数量向下截断，而不是返回错误
+	Round bool
+	// 可选，返回指定交易对允许的价格范围，ok为false表示不限制
+	PriceLimit func(symbol string) (min, max data_type.Decimal, ok bool)
+}
+
+/// 创建订单校验器
+func NewOrderValidator(r *SymbolRegistry) *OrderValidator {
+	return &OrderValidator{Registry: r}
+}
+
+/// 检查订单，Round为true时会修改req中的价格和数量
+func (v *OrderValidator) Validate(req *PlaceOrderRequest) error {
+	s, ok := v.Registry.Get(req.Symbol)
+	if !ok {
+		return &OrderValidationError{req.Symbol, "symbol", "unknown symbol"}
+	}
+	invalid := func(field, format string, a ...interface{}) error {
+		return &OrderValidationError{req.Symbol, field, fmt.Sprintf(format, a...)}
+	}
+	if s.State != SymbolStateOnline {
+		return invalid("symbol", "state is %s", s.State)
+	}
+	if s.APITrading == "disabled" {
+		return invalid("symbol", "api trading disabled")
+	}
+
+	// 市价买单的数量为计价币种金额
+	amountPrecision := s.AmountPrecision
+	if req.Type == OrderTypeBuyMarket {
+		amountPrecision = s.ValuePrecision
+	}
+	amount, err := v.checkPrecision(req.Amount, amountPrecision)
+	if err != nil {
+		return invalid("amount", "%s", err)
+	}
+	req.Amount = amount.String()
+	if amount.Sign() <= 0 {
+		return invalid("amount", "must be greater than 0")
+	}
+
+	switch req.Type {
+	case OrderTypeBuyMarket:
+		if s.MinOrderValue.Sign() > 0 && amount.LessThan(s.MinOrderValue) {
+			return invalid("amount", "order value %s less than %s", req.Amount, s.MinOrderValue)
+		}
+		if s.BuyMarketMaxOrderValue.Sign() > 0 && amount.GreaterThan(s.BuyMarketMaxOrderValue) {
+			return invalid("amount", "order value %s greater than %s", req.Amount, s.BuyMarketMaxOrderValue)
+		}
+		return nil
+	case OrderTypeSellMarket:
+		return checkAmountRange(invalid, amount,
+			firstPositive(s.SellMarketMinOrderAmt, s.MinOrderAmt),
+			firstPositive(s.SellMarketMaxOrderAmt, s.MaxOrderAmt))
+	}
+
+	price, err := v.checkPrecision(req.Price, s.PricePrecision)
+	if err != nil {
+		return invalid("price", "%s", err)
+	}
+	req.Price = price.String()
+	if price.Sign() <= 0 {
+		return invalid("price", "must be greater than 0")
+	}
+	if v.PriceLimit != nil {
+		if min, max, ok := v.PriceLimit(req.Symbol); ok &&
+			(price.LessThan(min) || price.GreaterThan(max)) {
+			return invalid("price", "%s out of range [%s, %s]", req.Price, min, max)
+		}
+	}
+	if err := checkAmountRange(invalid, amount,
+		firstPositive(s.LimitOrderMinOrderAmt, s.MinOrderAmt),
+		firstPositive(s.LimitOrderMaxOrderAmt, s.MaxOrderAmt)); err != nil {
+		return err
+	}
+	if value := price.Mul(amount); s.MinOrderValue.Sign() > 0 && value.LessThan(s.MinOrderValue) {
+		return invalid("amount", "order value %s less than %s", value.Normalize(), s.MinOrderValue)
+	}
+	return nil
+}
+
+/// 检查小数位数，Round为true时截断超出的部分
+func (v *OrderValidator) checkPrecision(value string, precision int) (data_type.Decimal, error) {
+	d, err := data_type.ParseDecimal(value)
+	if err != nil || strings.ContainsAny(value, "eE") {
+		return d, fmt.Errorf("%q is not a decimal number", value)
+	}
+	if int(d.Normalize().Scale()) <= precision {
+		return d, nil
+	}
+	if !v.Round {
+		return d, fmt.Errorf("%s has more than %d decimal places", value, precision)
+	}
+	return d.Truncate(int32(precision)), nil
+}
+
+func checkAmountRange(invalid func(field, format string, a ...interface{}) error, amount, min, max data_type.Decimal) error {
+	if min.Sign() > 0 && amount.LessThan(min) {
+		return invalid("amount", "%s less than %s", amount, min)
+	}
+	if max.Sign() > 0 && amount.GreaterThan(max) {
+		return invalid("amount", "%s greater than %s", amount, max)
+	}
+	return nil
+}
+
+func firstPositive(a, b data_type.Decimal) data_type.Decimal {
+	if a.Sign() > 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/stretchr/testify/assert"
+)
+
+var dec = data_type.MustParseDecimal
+
+func newTestSymbolRegistry() *SymbolRegistry {
+	r := NewSymbolRegistry(nil)
+	r.Set([]Symbol{
+		{Symbol: "eosusdt", State: SymbolStateOnline, PricePrecision: 4, AmountPrecision: 2, ValuePrecision: 8,
+			MinOrderAmt: dec("0.1"), MaxOrderAmt: dec("100000"), MinOrderValue: dec("5"), LimitOrderMinOrderAmt: dec("0.1"), LimitOrderMaxOrderAmt: dec("50000"),
+			SellMarketMinOrderAmt: dec("0.1"), SellMarketMaxOrderAmt: dec("10000"), BuyMarketMaxOrderValue: dec("100000")},
+		// 限制的小数位数超过float64能精确表示的范围
+		{Symbol: "shibusdt", State: SymbolStateOnline, PricePrecision: 10, AmountPrecision: 0, ValuePrecision: 8,
+			MinOrderValue: dec("5.0000000000000001")},
+		{Symbol: "xyzbtc", State: SymbolStateOffline, PricePrecision: 8, AmountPrecision: 2},
+	})
+	return r
+}
+
+func TestOrderValidator(t *testing.T) {
+	v := NewOrderValidator(newTestSymbolRegistry())
+	order := func(typ OrderType, amount, price string) *PlaceOrderRequest {
+		return &PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: typ, Amount: amount, Price: price}
+	}
+
+	assert.NoError(t, v.Validate(order(OrderTypeBuyLimit, "1.50", "14.2900")))
+	assert.EqualError(t, v.Validate(&PlaceOrderRequest{Symbol: "abcusdt"}), "invalid symbol for abcusdt: unknown symbol")
+	assert.EqualError(t, v.Validate(&PlaceOrderRequest{Symbol: "xyzbtc"}), "invalid symbol for xyzbtc: state is offline")
+
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "1.505", "14.29")), "invalid amount for eosusdt: 1.505 has more than 2 decimal places")
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "1.5", "14.29001")), "invalid price for eosusdt: 14.29001 has more than 4 decimal places")
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "1e2", "14.29")), `invalid amount for eosusdt: "1e2" is not a decimal number`)
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "0.05", "14.29")), "invalid amount for eosusdt: 0.05 less than 0.1")
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "60000", "14.29")), "invalid amount for eosusdt: 60000 greater than 50000")
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "0.3", "14.29")), "invalid amount for eosusdt: order value 4.287 less than 5")
+	assert.EqualError(t, v.Validate(order(OrderTypeSellLimit, "1", "0")), "invalid price for eosusdt: must be greater than 0")
+
+	assert.NoError(t, v.Validate(order(OrderTypeBuyMarket, "10.12345678", "")))
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyMarket, "4", "")), "invalid amount for eosusdt: order value 4 less than 5")
+	assert.NoError(t, v.Validate(order(OrderTypeSellMarket, "0.1", "")))
+	assert.EqualError(t, v.Validate(order(OrderTypeSellMarket, "20000", "")), "invalid amount for eosusdt: 20000 greater than 10000")
+
+	// 最小成交额按精确的十进制比较
+	shib := &PlaceOrderRequest{AccountID: 1, Symbol: "shibusdt", Type: OrderTypeBuyLimit, Amount: "1000000000", Price: "0.0000000050"}
+	assert.EqualError(t, v.Validate(shib), "invalid amount for shibusdt: order value 5 less than 5.0000000000000001")
+	shib.Price = "0.0000000051"
+	assert.NoError(t, v.Validate(shib))
+
+	v.PriceLimit = func(symbol string) (data_type.Decimal, data_type.Decimal, bool) { return dec("10"), dec("20"), true }
+	assert.EqualError(t, v.Validate(order(OrderTypeBuyLimit, "1", "21")), "invalid price for eosusdt: 21 out of range [10, 20]")
+
+	// 按精度截断
+	v.Round = true
+	req := order(OrderTypeBuyLimit, "1.5099", "14.299999")
+	assert.NoError(t, v.Validate(req))
+	assert.Equal(t, "1.50", req.Amount)
+	assert.Equal(t, "14.2999", req.Price)
+	req = order(OrderTypeBuyLimit, "0.001", "14.29")
+	assert.EqualError(t, v.Validate(req), "invalid amount for eosusdt: must be greater than 0")
+}
+
+func TestClient_WithOrderValidator(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"status":"ok","data":"1"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, "key", "secret", WithOrderValidator(NewOrderValidator(newTestSymbolRegistry())))
+	assert.NoError(t, err)
+	_, err = c.PlaceOrder(PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1.505", Price: "14.29"})
+	assert.IsType(t, &OrderValidationError{}, err)
+	_, err = c.BatchPlaceOrders([]PlaceOrderRequest{{AccountID: 1, Symbol: "xyzbtc", Type: OrderTypeBuyLimit, Amount: "1", Price: "1"}})
+	assert.EqualError(t, err, "order 0: invalid symbol for xyzbtc: state is offline")
+	assert.Equal(t, 0, requests)
+
+	id, err := c.PlaceOrder(PlaceOrderRequest{AccountID: 1, Symbol: "eosusdt", Type: OrderTypeBuyLimit, Amount: "1.5", Price: "14.29"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,51 @@
+package data_type
+
+import "encoding/json"
+
+type BBO struct {
+	Ch   string  `json:"ch"`
+	Ts   uint    `json:"ts"`
+	Tick BBOTick `json:"tick"`
+}
+
+type BBOTick struct {
+	Symbol    string  `json:"symbol"`
+	QuoteTime uint    `json:"quoteTime"`
+	SeqID     uint    `json:"seqId"`
+	Bid       float64 `json:"bid"`
+	BidSize   float64 `json:"bidSize"`
+	Ask       float64 `json:"ask"`
+	AskSize   float64 `json:"askSize"`
+}
+
+func DecodeBBO(raw []byte) (*BBO, error) {
+	var ret = &BBO{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type BBODecimal struct {
+	Ch   string         `json:"ch"`
+	Ts   uint           `json:"ts"`
+	Tick BBOTickDecimal `json:"tick"`
+}
+
+type BBOTickDecimal struct {
+	Symbol    string  `json:"symbol"`
+	QuoteTime uint    `json:"quoteTime"`
+	SeqID     uint    `json:"seqId"`
+	Bid       Decimal `json:"bid"`
+	BidSize   Decimal `json:"bidSize"`
+	Ask       Decimal `json:"ask"`
+	AskSize   Decimal `json:"askSize"`
+}
+
+func DecodeBBODecimal(raw []byte) (*BBODecimal, error) {
+	var ret = &BBODecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,25 @@
+package data_type
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBBO(t *testing.T) {
+	str := `{"ch":"market.eosusdt.bbo","ts":1516870811119,"tick":{"symbol":"eosusdt","quoteTime":1516870811101,"bid":14.29,"bidSize":21.5757,"ask":14.3,"askSize":1193.5857,"seqId":10242474683}}`
+	data, err := DecodeBBO([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.eosusdt.bbo", data.Ch)
+	assert.Equal(t, "eosusdt", data.Tick.Symbol)
+	assert.Equal(t, uint(1516870811101), data.Tick.QuoteTime)
+	assert.Equal(t, uint(10242474683), data.Tick.SeqID)
+	assert.Equal(t, 14.29, data.Tick.Bid)
+	assert.Equal(t, 1193.5857, data.Tick.AskSize)
+
+	dec, err := DecodeBBODecimal([]byte(str))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.01", dec.Tick.Ask.Sub(dec.Tick.Bid).String())
+}
@@ -0,0 +1,344 @@
+package data_type
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode 舍入方式
+type RoundingMode int
+
+const (
+	// RoundDown 向零舍入（截断）
+	RoundDown RoundingMode = iota
+	// RoundUp 远离零舍入
+	RoundUp
+	// RoundHalfUp 四舍五入
+	RoundHalfUp
+	// RoundHalfEven 四舍六入五取偶
+	RoundHalfEven
+	// RoundFloor 向负无穷舍入
+	RoundFloor
+	// RoundCeiling 向正无穷舍入
+	RoundCeiling
+)
+
+// Decimal 任意精度的十进制定点数，值为 value * 10^(-scale)
+// 零值表示0，所有运算都返回新的值，可以安全地在多个goroutine中共享
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// MaxScale 小数位数的最大绝对值，解析时指数超出范围返回错误，避免计算10的超大次方时耗尽内存
+const MaxScale = 1000
+
+var bigTen = big.NewInt(10)
+
+// pow10 返回10的n次方
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(n), nil)
+}
+
+// NewDecimal 创建值为 value * 10^(-scale) 的Decimal，scale的绝对值超过MaxScale时panic
+func NewDecimal(value int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(value), int64(scale))
+}
+
+// NewDecimalFromInt 从整数创建Decimal
+func NewDecimalFromInt(value int64) Decimal {
+	return NewDecimal(value, 0)
+}
+
+// NewDecimalFromFloat 从浮点数创建Decimal，使用能唯一表示该浮点数的最短十进制形式
+func NewDecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// newDecimal 创建Decimal，scale为负数时转换为整数
+func newDecimal(value *big.Int, scale int64) Decimal {
+	if scale < -MaxScale || scale > MaxScale {
+		panic(fmt.Sprintf("decimal scale %d out of range", scale))
+	}
+	if scale < 0 {
+		value = new(big.Int).Mul(value, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{value: value, scale: int32(scale)}
+}
+
+// ParseDecimal 解析十进制数字符串，支持科学计数法，例如 -14.29、1.5e-3
+// 小数位数（考虑指数后）的绝对值不能超过MaxScale
+func ParseDecimal(s string) (Decimal, error) {
+	str := s
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+		}
+		exp = e
+		str = str[:i]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := strings.TrimLeft(intPart, "+-")
+	if len(intPart)-len(digits) > 1 || !isDigits(digits) || !isDigits(fracPart) || digits+fracPart == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+	scale := int64(len(fracPart)) - exp
+	if scale < -MaxScale || scale > MaxScale {
+		return Decimal{}, fmt.Errorf("decimal scale out of range: %q", s)
+	}
+	return newDecimal(value, scale), nil
+}
+
+// MustParseDecimal 解析十进制数字符串，出错时panic
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// val 返回内部的整数值，零值Decimal返回0
+func (d Decimal) val() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// rescale 返回小数位数调整为scale的整数值，scale不能小于当前小数位数
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.val()
+	}
+	return new(big.Int).Mul(d.val(), pow10(int64(scale-d.scale)))
+}
+
+// align 将两个数调整为相同的小数位数
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+// Scale 小数位数
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// String 返回不使用科学计数法的字符串，保留末尾的0
+func (d Decimal) String() string {
+	v := d.val()
+	abs := new(big.Int).Abs(v).String()
+	if d.scale > 0 {
+		if len(abs) <= int(d.scale) {
+			abs = strings.Repeat("0", int(d.scale)-len(abs)+1) + abs
+		}
+		i := len(abs) - int(d.scale)
+		abs = abs[:i] + "." + abs[i:]
+	}
+	if v.Sign() < 0 {
+		return "-" + abs
+	}
+	return abs
+}
+
+// StringFixed 返回四舍五入到places位小数的字符串
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places, RoundHalfUp).String()
+}
+
+// Float64 转换为浮点数，可能丢失精度
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Sign 符号，负数返回-1，0返回0，正数返回1
+func (d Decimal) Sign() int {
+	return d.val().Sign()
+}
+
+// IsZero 是否为0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg 相反数
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.val()), scale: d.scale}
+}
+
+// Abs 绝对值
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.val()), scale: d.scale}
+}
+
+// Add 加法
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub 减法
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul 乘法，结果的小数位数为两数小数位数之和
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.val(), d2.val()), scale: d.scale + d2.scale}
+}
+
+// Div 除法，结果按mode舍入到scale位小数，除数为0时panic
+func (d Decimal) Div(d2 Decimal, scale int32, mode RoundingMode) Decimal {
+	if d2.IsZero() {
+		panic("decimal division by zero")
+	}
+	// d / d2 = (d.value / d2.value) * 10^(d2.scale - d.scale)
+	num := new(big.Int).Set(d.val())
+	den := new(big.Int).Set(d2.val())
+	if k := int64(scale) + int64(d2.scale) - int64(d.scale); k >= 0 {
+		num.Mul(num, pow10(k))
+	} else {
+		den.Mul(den, pow10(-k))
+	}
+	return newDecimal(quoRound(num, den, mode), int64(scale))
+}
+
+// Round 按mode舍入到places位小数，places为负数时舍入到整数位，例如-1表示舍入到十位
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places >= d.scale {
+		return Decimal{value: d.rescale(places), scale: places}
+	}
+	q := quoRound(d.val(), pow10(int64(d.scale-places)), mode)
+	return newDecimal(q, int64(places))
+}
+
+// Truncate 截断到places位小数
+func (d Decimal) Truncate(places int32) Decimal {
+	return d.Round(places, RoundDown)
+}
+
+// Normalize 去掉小数部分末尾的0
+func (d Decimal) Normalize() Decimal {
+	v := new(big.Int).Set(d.val())
+	scale := d.scale
+	r := new(big.Int)
+	for scale > 0 && v.Sign() != 0 {
+		q, m := new(big.Int).QuoRem(v, bigTen, r)
+		if m.Sign() != 0 {
+			break
+		}
+		v = q
+		scale--
+	}
+	if v.Sign() == 0 {
+		scale = 0
+	}
+	return Decimal{value: v, scale: scale}
+}
+
+// Cmp 比较大小，d小于、等于、大于d2时分别返回-1、0、1
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// Equal 数值是否相等，忽略末尾的0
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// LessThan 是否小于d2
+func (d Decimal) LessThan(d2 Decimal) bool {
+	return d.Cmp(d2) < 0
+}
+
+// GreaterThan 是否大于d2
+func (d Decimal) GreaterThan(d2 Decimal) bool {
+	return d.Cmp(d2) > 0
+}
+
+// UnmarshalJSON 解析JSON数字或字符串，保留原始精度，null和空字符串解析为0
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" || s == `""` {
+		*d = Decimal{}
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON 输出为JSON数字，保留原始精度
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// quoRound 计算num/den并按mode舍入为整数
+func quoRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// 商的真实值为负数时，远离零即为减1
+	neg := r.Sign()*den.Sign() < 0
+	var away bool
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = neg
+	case RoundCeiling:
+		away = !neg
+	case RoundHalfUp, RoundHalfEven:
+		c := new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(new(big.Int).Abs(den))
+		away = c > 0 || (c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1))
+	}
+	if away {
+		if neg {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
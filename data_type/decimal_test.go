@@ -0,0 +1,137 @@
+package data_type
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"14.290000000000000000": "14.290000000000000000",
+		"0":                     "0",
+		"-0.5":                  "-0.5",
+		".5":                    "0.5",
+		"+3.":                   "3",
+		"1.5e-3":                "0.0015",
+		"1.5E3":                 "1500",
+		"-12e-1":                "-1.2",
+		"0.000001":              "0.000001",
+	}
+	for in, out := range cases {
+		d, err := ParseDecimal(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, out, d.String(), in)
+	}
+	for _, in := range []string{"", ".", "-", "1.2.3", "--1", "1-", "abc", "1e", "0x10", "1_000", " 1"} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+
+	// 指数超出范围时返回错误，而不是计算10的超大次方
+	for _, in := range []string{"1e2000000000", "1e-2000000000", "1e1001", "1e99999999999"} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+	d, err := ParseDecimal("1e1000")
+	assert.NoError(t, err)
+	assert.Equal(t, 1001, len(d.String()))
+	assert.Panics(t, func() { NewDecimal(1, -2000000000) })
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.True(t, a.Add(b).Equal(MustParseDecimal("0.30")))
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "4.287", MustParseDecimal("14.29").Mul(MustParseDecimal("0.3")).String())
+	assert.Equal(t, "0.3333", NewDecimalFromInt(1).Div(NewDecimalFromInt(3), 4, RoundHalfUp).String())
+	assert.Equal(t, "0.6667", NewDecimalFromInt(2).Div(NewDecimalFromInt(3), 4, RoundHalfUp).String())
+	assert.Equal(t, "-0.6666", NewDecimalFromInt(-2).Div(NewDecimalFromInt(3), 4, RoundDown).String())
+	assert.Equal(t, "120", MustParseDecimal("1.2").Div(MustParseDecimal("0.01"), 0, RoundDown).String())
+	assert.Panics(t, func() { a.Div(Decimal{}, 2, RoundDown) })
+
+	var zero Decimal
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, "0", zero.String())
+	assert.Equal(t, "0.1", zero.Add(a).String())
+	assert.Equal(t, "-0.1", a.Neg().String())
+	assert.Equal(t, "0.1", a.Neg().Abs().String())
+	assert.Equal(t, 0.3, a.Add(b).Float64())
+	assert.Equal(t, "0.1", NewDecimalFromFloat(0.1).String())
+	assert.Equal(t, "1.23", NewDecimal(123, 2).String())
+	assert.Equal(t, "12300", NewDecimal(123, -2).String())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	cases := []struct {
+		in   string
+		mode RoundingMode
+		pos  string
+		neg  string
+	}{
+		{"1.25", RoundDown, "1.2", "-1.2"},
+		{"1.25", RoundUp, "1.3", "-1.3"},
+		{"1.25", RoundHalfUp, "1.3", "-1.3"},
+		{"1.25", RoundHalfEven, "1.2", "-1.2"},
+		{"1.35", RoundHalfEven, "1.4", "-1.4"},
+		{"1.24", RoundHalfUp, "1.2", "-1.2"},
+		{"1.26", RoundHalfEven, "1.3", "-1.3"},
+		{"1.25", RoundFloor, "1.2", "-1.3"},
+		{"1.25", RoundCeiling, "1.3", "-1.2"},
+	}
+	for _, c := range cases {
+		d := MustParseDecimal(c.in)
+		assert.Equal(t, c.pos, d.Round(1, c.mode).String(), "%s %d", c.in, c.mode)
+		assert.Equal(t, c.neg, d.Neg().Round(1, c.mode).String(), "-%s %d", c.in, c.mode)
+	}
+	d := MustParseDecimal("14.2999")
+	assert.Equal(t, "14.29", d.Truncate(2).String())
+	assert.Equal(t, "14.30", d.StringFixed(2))
+	assert.Equal(t, "14.299900", d.Round(6, RoundDown).String())
+	assert.Equal(t, "10", d.Round(-1, RoundHalfUp).String())
+	assert.Equal(t, "14.29", MustParseDecimal("14.290000").Normalize().String())
+	assert.Equal(t, "0", MustParseDecimal("0.000").Normalize().String())
+	assert.Equal(t, "100", MustParseDecimal("100").Normalize().String())
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	a := MustParseDecimal("14.29")
+	b := MustParseDecimal("14.290000000000000000")
+	c := MustParseDecimal("14.3")
+	assert.Equal(t, 0, a.Cmp(b))
+	assert.True(t, a.Equal(b))
+	assert.True(t, a.LessThan(c))
+	assert.True(t, c.GreaterThan(b))
+	assert.Equal(t, -1, c.Neg().Sign())
+	assert.Equal(t, 18, int(b.Scale()))
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var v struct {
+		A Decimal  `json:"a"`
+		B Decimal  `json:"b"`
+		C Decimal  `json:"c"`
+		D *Decimal `json:"d"`
+	}
+	err := json.Unmarshal([]byte(`{"a":4010.253191000000000000000000000000000000,"b":"0.1","c":null,"d":1e-8}`), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, "4010.253191000000000000000000000000000000", v.A.String())
+	assert.Equal(t, "0.1", v.B.String())
+	assert.True(t, v.C.IsZero())
+	assert.Equal(t, "0.00000001", v.D.String())
+
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":4010.253191000000000000000000000000000000,"b":0.1,"c":0,"d":0.00000001}`, string(b))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":""}`), &v))
+	assert.True(t, v.A.IsZero())
+	assert.Error(t, json.Unmarshal([]byte(`{"a":true}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"a":"x"}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"a":1e2000000000}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"a":"1e2000000000"}`), &v))
+}
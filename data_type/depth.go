@@ -18,3 +18,20 @@ func DecodeDepth(raw []byte) (*Depth, error) {
 	}
 	return ret, nil
 }
+
+type DepthDecimal struct {
+	Ch   string `json:"ch"`
+	Ts   uint   `json:"ts"`
+	Tick struct {
+		Bids [][]Decimal `json:"bids"`
+		Asks [][]Decimal `json:"asks"`
+	} `json:"tick"`
+}
+
+func DecodeDepthDecimal(raw []byte) (*DepthDecimal, error) {
+	var ret = &DepthDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -17,3 +17,14 @@ func TestDecodeDepth(t *testing.T) {
 	assert.Equal(t, []float64{14.3, 1193.5857}, data.Tick.Asks[0])
 	assert.Equal(t, []float64{14.29, 21.5757}, data.Tick.Bids[0])
 }
+
+func TestDecodeDepthDecimal(t *testing.T) {
+	str := `{"ch":"market.eosusdt.depth.step0","tick":{"asks":[[14.300000000000000000,1193.585700000000000000],[14.310000000000000000,2381.404600000000000000]],"bids":[[14.290000000000000000,21.575700000000000000],[14.120000000000000000,1758.332483984472204246]],"ts":1516870810023,"version":1557850542},"ts":1516870811119}`
+	data, err := DecodeDepthDecimal([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.eosusdt.depth.step0", data.Ch)
+	assert.Equal(t, "14.3", data.Tick.Asks[0][0].Normalize().String())
+	assert.Equal(t, "1193.5857", data.Tick.Asks[0][1].Normalize().String())
+	assert.Equal(t, "1758.332483984472204246", data.Tick.Bids[1][1].String())
+}
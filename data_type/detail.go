@@ -0,0 +1,113 @@
+package data_type
+
+import "encoding/json"
+
+type MarketDetail struct {
+	Ch   string           `json:"ch"`
+	Ts   uint             `json:"ts"`
+	Tick MarketDetailTick `json:"tick"`
+}
+
+type MarketDetailTick struct {
+	ID      uint    `json:"id"`
+	Amount  float64 `json:"amount"`
+	Count   uint    `json:"count"`
+	Open    float64 `json:"open"`
+	Close   float64 `json:"close"`
+	Low     float64 `json:"low"`
+	High    float64 `json:"high"`
+	Vol     float64 `json:"vol"`
+	Version uint    `json:"version"`
+}
+
+type MergedDetail struct {
+	Ch   string           `json:"ch"`
+	Ts   uint             `json:"ts"`
+	Tick MergedDetailTick `json:"tick"`
+}
+
+type MergedDetailTick struct {
+	ID      uint      `json:"id"`
+	Amount  float64   `json:"amount"`
+	Count   uint      `json:"count"`
+	Open    float64   `json:"open"`
+	Close   float64   `json:"close"`
+	Low     float64   `json:"low"`
+	High    float64   `json:"high"`
+	Vol     float64   `json:"vol"`
+	Bid     []float64 `json:"bid"`
+	Ask     []float64 `json:"ask"`
+	Version uint      `json:"version"`
+	Ts      uint      `json:"ts"`
+}
+
+func DecodeMarketDetail(raw []byte) (*MarketDetail, error) {
+	var ret = &MarketDetail{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func DecodeMergedDetail(raw []byte) (*MergedDetail, error) {
+	var ret = &MergedDetail{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type MarketDetailDecimal struct {
+	Ch   string                  `json:"ch"`
+	Ts   uint                    `json:"ts"`
+	Tick MarketDetailTickDecimal `json:"tick"`
+}
+
+type MarketDetailTickDecimal struct {
+	ID      uint    `json:"id"`
+	Amount  Decimal `json:"amount"`
+	Count   uint    `json:"count"`
+	Open    Decimal `json:"open"`
+	Close   Decimal `json:"close"`
+	Low     Decimal `json:"low"`
+	High    Decimal `json:"high"`
+	Vol     Decimal `json:"vol"`
+	Version uint    `json:"version"`
+}
+
+type MergedDetailDecimal struct {
+	Ch   string                  `json:"ch"`
+	Ts   uint                    `json:"ts"`
+	Tick MergedDetailTickDecimal `json:"tick"`
+}
+
+type MergedDetailTickDecimal struct {
+	ID      uint      `json:"id"`
+	Amount  Decimal   `json:"amount"`
+	Count   uint      `json:"count"`
+	Open    Decimal   `json:"open"`
+	Close   Decimal   `json:"close"`
+	Low     Decimal   `json:"low"`
+	High    Decimal   `json:"high"`
+	Vol     Decimal   `json:"vol"`
+	Bid     []Decimal `json:"bid"`
+	Ask     []Decimal `json:"ask"`
+	Version uint      `json:"version"`
+	Ts      uint      `json:"ts"`
+}
+
+func DecodeMarketDetailDecimal(raw []byte) (*MarketDetailDecimal, error) {
+	var ret = &MarketDetailDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func DecodeMergedDetailDecimal(raw []byte) (*MergedDetailDecimal, error) {
+	var ret = &MergedDetailDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,51 @@
+package data_type
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeMarketDetail(t *testing.T) {
+	str := `{"ch":"market.eosusdt.detail","ts":1516870811119,"tick":{"amount":1207461.447300000000000000,"open":13.430000000000000000,"close":14.290000000000000000,"high":14.560000000000000000,"id":1557850542,"count":21640,"low":13.220000000000000000,"version":1557850542,"vol":16866489.573540463000000000000000000000000000}}`
+	data, err := DecodeMarketDetail([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.eosusdt.detail", data.Ch)
+	assert.Equal(t, uint(1516870811119), data.Ts)
+	assert.Equal(t, uint(1557850542), data.Tick.ID)
+	assert.Equal(t, 1207461.4473, data.Tick.Amount)
+	assert.Equal(t, uint(21640), data.Tick.Count)
+	assert.Equal(t, 13.43, data.Tick.Open)
+	assert.Equal(t, 14.29, data.Tick.Close)
+	assert.Equal(t, 14.56, data.Tick.High)
+	assert.Equal(t, 13.22, data.Tick.Low)
+	assert.Equal(t, uint(1557850542), data.Tick.Version)
+}
+
+func TestDecodeMergedDetail(t *testing.T) {
+	str := `{"status":"ok","ch":"market.eosusdt.detail.merged","ts":1516870811119,"tick":{"amount":1207461.4473,"open":13.43,"close":14.29,"high":14.56,"id":1557850542,"count":21640,"low":13.22,"version":1557850542,"ask":[14.3,1193.5857],"vol":16866489.57354,"bid":[14.29,21.5757],"ts":1516870810023}}`
+	data, err := DecodeMergedDetail([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.eosusdt.detail.merged", data.Ch)
+	assert.Equal(t, []float64{14.29, 21.5757}, data.Tick.Bid)
+	assert.Equal(t, []float64{14.3, 1193.5857}, data.Tick.Ask)
+	assert.Equal(t, uint(1516870810023), data.Tick.Ts)
+	assert.Equal(t, 14.29, data.Tick.Close)
+}
+
+func TestDecodeMergedDetailDecimal(t *testing.T) {
+	str := `{"status":"ok","ch":"market.eosusdt.detail.merged","ts":1516870811119,"tick":{"amount":1207461.4473,"open":13.43,"close":14.29,"high":14.56,"id":1557850542,"count":21640,"low":13.22,"version":1557850542,"ask":[14.3,1193.5857],"vol":16866489.57354,"bid":[14.29,21.5757],"ts":1516870810023}}`
+	data, err := DecodeMergedDetailDecimal([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "14.29", data.Tick.Bid[0].String())
+	assert.Equal(t, "1193.5857", data.Tick.Ask[1].String())
+	assert.Equal(t, "16866489.57354", data.Tick.Vol.String())
+
+	md, err := DecodeMarketDetailDecimal([]byte(`{"ch":"market.eosusdt.detail","ts":1516870811119,"tick":{"amount":1207461.447300000000000000,"open":13.43,"id":1}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "13.43", md.Tick.Open.String())
+}
@@ -26,3 +26,28 @@ func DecodeKline(raw []byte) (*Kline, error) {
 	}
 	return ret, nil
 }
+
+type KlineDecimal struct {
+	Ch   string           `json:"ch"`
+	Ts   uint             `json:"ts"`
+	Tick KlineTickDecimal `json:"tick"`
+}
+
+type KlineTickDecimal struct {
+	ID     uint    `json:"id"`
+	Amount Decimal `json:"amount"`
+	Count  uint    `json:"count"`
+	Open   Decimal `json:"open"`
+	Close  Decimal `json:"close"`
+	Low    Decimal `json:"low"`
+	High   Decimal `json:"high"`
+	Vol    Decimal `json:"vol"`
+}
+
+func DecodeKlineDecimal(raw []byte) (*KlineDecimal, error) {
+	var ret = &KlineDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
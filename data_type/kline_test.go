@@ -23,3 +23,15 @@ func TestDecodeKLine(t *testing.T) {
 	assert.Equal(t, uint(1516870800), data.Tick.ID)
 	assert.Equal(t, 4010.253191, data.Tick.Vol)
 }
+
+func TestDecodeKLineDecimal(t *testing.T) {
+	str := `{"ch":"market.eosusdt.kline.1min","tick":{"amount":280.483600000000000000,"close":14.290000000000000000,"count":9,"high":14.300000000000000000,"id":1516870800,"low":14.290000000000000000,"open":14.290000000000000000,"vol":4010.253191000000000000000000000000000000},"ts":1516870810953}`
+	data, err := DecodeKlineDecimal([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.eosusdt.kline.1min", data.Ch)
+	assert.Equal(t, "280.483600000000000000", data.Tick.Amount.String())
+	assert.True(t, data.Tick.High.Equal(MustParseDecimal("14.3")))
+	assert.Equal(t, "4010.253191", data.Tick.Vol.Normalize().String())
+	assert.Equal(t, uint(9), data.Tick.Count)
+}
@@ -0,0 +1,45 @@
+package data_type
+
+import "encoding/json"
+
+type MBP struct {
+	Ch   string  `json:"ch"`
+	Ts   uint    `json:"ts"`
+	Tick MBPTick `json:"tick"`
+}
+
+type MBPTick struct {
+	SeqNum     uint        `json:"seqNum"`
+	PrevSeqNum uint        `json:"prevSeqNum"`
+	Bids       [][]float64 `json:"bids"`
+	Asks       [][]float64 `json:"asks"`
+}
+
+func DecodeMBP(raw []byte) (*MBP, error) {
+	var ret = &MBP{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type MBPDecimal struct {
+	Ch   string         `json:"ch"`
+	Ts   uint           `json:"ts"`
+	Tick MBPTickDecimal `json:"tick"`
+}
+
+type MBPTickDecimal struct {
+	SeqNum     uint        `json:"seqNum"`
+	PrevSeqNum uint        `json:"prevSeqNum"`
+	Bids       [][]Decimal `json:"bids"`
+	Asks       [][]Decimal `json:"asks"`
+}
+
+func DecodeMBPDecimal(raw []byte) (*MBPDecimal, error) {
+	var ret = &MBPDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
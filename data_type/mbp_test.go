@@ -0,0 +1,25 @@
+package data_type
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeMBP(t *testing.T) {
+	str := `{"ch":"market.btcusdt.mbp.5","ts":1573199608679,"tick":{"seqNum":100020146795,"prevSeqNum":100020146794,"bids":[[9069.29,0.0]],"asks":[[9069.35,1.2058],[9069.53,0.0101]]}}`
+	data, err := DecodeMBP([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, "market.btcusdt.mbp.5", data.Ch)
+	assert.Equal(t, uint(100020146795), data.Tick.SeqNum)
+	assert.Equal(t, uint(100020146794), data.Tick.PrevSeqNum)
+	assert.Equal(t, [][]float64{{9069.29, 0}}, data.Tick.Bids)
+	assert.Len(t, data.Tick.Asks, 2)
+
+	dec, err := DecodeMBPDecimal([]byte(str))
+	assert.NoError(t, err)
+	assert.True(t, dec.Tick.Bids[0][1].IsZero())
+	assert.Equal(t, "0.06", dec.Tick.Asks[0][0].Sub(dec.Tick.Bids[0][0]).String())
+}
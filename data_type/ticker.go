@@ -0,0 +1,49 @@
+package data_type
+
+import "encoding/json"
+
+type Ticker struct {
+	Symbol  string  `json:"symbol"`
+	Open    float64 `json:"open"`
+	High    float64 `json:"high"`
+	Low     float64 `json:"low"`
+	Close   float64 `json:"close"`
+	Amount  float64 `json:"amount"`
+	Vol     float64 `json:"vol"`
+	Count   uint    `json:"count"`
+	Bid     float64 `json:"bid"`
+	BidSize float64 `json:"bidSize"`
+	Ask     float64 `json:"ask"`
+	AskSize float64 `json:"askSize"`
+}
+
+func DecodeTickers(raw []byte) ([]Ticker, error) {
+	var ret []Ticker
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type TickerDecimal struct {
+	Symbol  string  `json:"symbol"`
+	Open    Decimal `json:"open"`
+	High    Decimal `json:"high"`
+	Low     Decimal `json:"low"`
+	Close   Decimal `json:"close"`
+	Amount  Decimal `json:"amount"`
+	Vol     Decimal `json:"vol"`
+	Count   uint    `json:"count"`
+	Bid     Decimal `json:"bid"`
+	BidSize Decimal `json:"bidSize"`
+	Ask     Decimal `json:"ask"`
+	AskSize Decimal `json:"askSize"`
+}
+
+func DecodeTickersDecimal(raw []byte) ([]TickerDecimal, error) {
+	var ret []TickerDecimal
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
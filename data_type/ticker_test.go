@@ -0,0 +1,33 @@
+package data_type
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTickers(t *testing.T) {
+	str := `[{"symbol":"eosusdt","open":13.43,"high":14.56,"low":13.22,"close":14.29,"amount":1207461.4473,"vol":16866489.57354,"count":21640,"bid":14.29,"bidSize":21.5757,"ask":14.3,"askSize":1193.5857},{"symbol":"btcusdt","open":10950.01,"high":11247.3,"low":10798.12,"close":11139.6,"amount":16253.4713,"vol":179896034.5,"count":162315,"bid":11139.59,"bidSize":0.1,"ask":11139.6,"askSize":0.4}]`
+	data, err := DecodeTickers([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Len(t, data, 2)
+	assert.Equal(t, "eosusdt", data[0].Symbol)
+	assert.Equal(t, 14.29, data[0].Close)
+	assert.Equal(t, uint(21640), data[0].Count)
+	assert.Equal(t, 14.29, data[0].Bid)
+	assert.Equal(t, 21.5757, data[0].BidSize)
+	assert.Equal(t, 14.3, data[0].Ask)
+	assert.Equal(t, 1193.5857, data[0].AskSize)
+	assert.Equal(t, "btcusdt", data[1].Symbol)
+}
+
+func TestDecodeTickersDecimal(t *testing.T) {
+	str := `[{"symbol":"eosusdt","open":13.43,"high":14.56,"low":13.22,"close":14.29,"amount":1207461.4473,"vol":16866489.57354,"count":21640,"bid":14.29,"bidSize":21.5757,"ask":14.3,"askSize":1193.5857}]`
+	data, err := DecodeTickersDecimal([]byte(str))
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "14.29", data[0].Bid.String())
+	assert.Equal(t, "0.01", data[0].Ask.Sub(data[0].Bid).String())
+}
@@ -27,3 +27,29 @@ func DecodeTrade(raw []byte) (*Trade, error) {
 	}
 	return ret, nil
 }
+
+type TradeDecimal struct {
+	Ch   string `json:"ch"`
+	Ts   uint   `json:"ts"`
+	Tick struct {
+		ID   uint               `json:"id"`
+		Ts   uint               `json:"ts"`
+		Data []TradeItemDecimal `json:"data"`
+	} `json:"tick"`
+}
+
+type TradeItemDecimal struct {
+	Ts        uint    `json:"ts"`
+	ID        uint    `json:"id"`
+	Direction string  `json:"direction"`
+	Amount    Decimal `json:"amount"`
+	Price     Decimal `json:"price"`
+}
+
+func DecodeTradeDecimal(raw []byte) (*TradeDecimal, error) {
+	var ret = &TradeDecimal{}
+	if err := json.Unmarshal(raw, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
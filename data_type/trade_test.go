@@ -22,3 +22,14 @@ func TestDecodeTrade(t *testing.T) {
 	assert.Equal(t, 8.3334, data.Tick.Data[0].Amount)
 	assert.Equal(t, 14.29, data.Tick.Data[0].Price)
 }
+
+func TestDecodeTradeDecimal(t *testing.T) {
+	str := `{"ch":"market.eosusdt.trade.detail","tick":{"data":[{"amount":8.333400000000000000,"direction":"sell","id":17592232489498,"price":14.290000000000000000,"ts":1516870810708}],"id":1557850942,"ts":1516870810708},"ts":1516870811033}`
+	data, err := DecodeTradeDecimal([]byte(str))
+	assert.NoError(t, err)
+	fmt.Println(data)
+	assert.Equal(t, uint(17592232489498), data.Tick.Data[0].ID)
+	assert.Equal(t, "sell", data.Tick.Data[0].Direction)
+	assert.Equal(t, "8.3334", data.Tick.Data[0].Amount.Normalize().String())
+	assert.Equal(t, "14.29", data.Tick.Data[0].Price.Normalize().String())
+}
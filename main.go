@@ -2,6 +2,7 @@ package huobiapi
 
 import (
 	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/account"
 	"github.com/leizongmin/huobiapi/client"
 	"github.com/leizongmin/huobiapi/market"
 )
@@ -11,14 +12,45 @@ type JSON = simplejson.Json
 type ParamsData = client.ParamData
 type Market = market.Market
 type Listener = market.Listener
+type ListenerHandle = market.ListenerHandle
 type Client = client.Client
+type ClientOption = client.Option
+type APIError = client.APIError
+type Subscription = market.Subscription
+type StreamOption = market.StreamOption
+type MarketOption = market.Option
+type AccountClient = account.Client
+type AccountOption = account.Option
+
+/// 订阅通道缓冲区已满时的处理方式
+const (
+	OverflowDropOldest = market.OverflowDropOldest
+	OverflowDropNewest = market.OverflowDropNewest
+	OverflowBlock      = market.OverflowBlock
+)
+
+/// 设置订阅通道缓冲区大小
+var WithBuffer = market.WithBuffer
+
+/// 设置订阅通道缓冲区已满时的处理方式
+var WithOverflow = market.WithOverflow
 
 /// 创建WebSocket版Market客户端
 func NewMarket() (*market.Market, error) {
 	return market.NewMarket()
 }
 
+/// 创建WebSocket版Market客户端，可指定入口地址、Dialer、请求头、心跳间隔和重连策略等
+func NewMarketWithOptions(options ...MarketOption) (*market.Market, error) {
+	return market.NewMarketWithOptions(options...)
+}
+
 /// 创建RESTFul客户端
-func NewClient(accessKeyId, accessKeySecret string) (*client.Client, error) {
-	return client.NewClient(client.Endpoint, accessKeyId, accessKeySecret)
+func NewClient(accessKeyId, accessKeySecret string, options ...ClientOption) (*client.Client, error) {
+	return client.NewClient(client.Endpoint, accessKeyId, accessKeySecret, options...)
+}
+
+/// 创建WebSocket v2版资产和订单推送客户端，连接后自动鉴权
+func NewAccountClient(accessKeyId, accessKeySecret string, options ...AccountOption) (*account.Client, error) {
+	return account.NewClient(accessKeyId, accessKeySecret, options...)
 }
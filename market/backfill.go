@@ -0,0 +1,170 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/debug"
+	"github.com/leizongmin/huobiapi/market/kline"
+)
+
+// KlineRequestLimit 单次请求K线历史数据最多返回的数量
+const KlineRequestLimit = 300
+
+// InvalidPeriodError 不支持的K线周期，与kline.InvalidPeriodError相同
+var InvalidPeriodError = kline.InvalidPeriodError
+
+// DefaultBackfillRetryPolicy 请求K线历史数据时默认的重试策略，请求频率超限、超时或连接断开时重试
+var DefaultBackfillRetryPolicy = client.RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+type backfillOptions struct {
+	pageSize     int
+	pageInterval time.Duration
+	retry        client.RetryPolicy
+}
+
+// BackfillOption 请求K线历史数据的选项
+type BackfillOption func(o *backfillOptions)
+
+// WithPageSize 设置每页请求的K线数量，默认且最大为KlineRequestLimit
+func WithPageSize(n int) BackfillOption {
+	return func(o *backfillOptions) {
+		o.pageSize = n
+	}
+}
+
+// WithPageInterval 设置两次请求之间的最短间隔，用于控制请求频率，默认200毫秒
+func WithPageInterval(d time.Duration) BackfillOption {
+	return func(o *backfillOptions) {
+		o.pageInterval = d
+	}
+}
+
+// WithPageRetryPolicy 设置单页请求失败后的重试策略，默认为DefaultBackfillRetryPolicy
+func WithPageRetryPolicy(p client.RetryPolicy) BackfillOption {
+	return func(o *backfillOptions) {
+		o.retry = p
+	}
+}
+
+func newBackfillOptions(options []BackfillOption) *backfillOptions {
+	o := &backfillOptions{
+		pageSize:     KlineRequestLimit,
+		pageInterval: 200 * time.Millisecond,
+		retry:        DefaultBackfillRetryPolicy,
+	}
+	for _, fn := range options {
+		fn(o)
+	}
+	if o.pageSize <= 0 || o.pageSize > KlineRequestLimit {
+		o.pageSize = KlineRequestLimit
+	}
+	return o
+}
+
+// KlineHistory 请求symbol在[from, to]时间范围内period周期的K线历史数据，period按kline.ParsePeriod解析，必须是火币支持的周期，返回按时间升序排列且不重复的K线
+// 超过单次请求的数量限制时自动分页请求
+func (m *Market) KlineHistory(ctx context.Context, symbol, period string, from, to time.Time, options ...BackfillOption) ([]data_type.KlineTick, error) {
+	var ret []data_type.KlineTick
+	err := m.KlineHistoryPages(ctx, symbol, period, from, to, func(page []data_type.KlineTick) error {
+		ret = append(ret, page...)
+		return nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// KlineHistoryPages 分页请求symbol在[from, to]时间范围内period周期的K线历史数据，每收到一页调用一次fn
+// 各页按时间升序依次返回，页内和页之间的K线不重复，空页不调用fn；fn返回错误时停止请求并返回该错误
+func (m *Market) KlineHistoryPages(ctx context.Context, symbol, period string, from, to time.Time, fn func(page []data_type.KlineTick) error, options ...BackfillOption) error {
+	p, err := kline.ParsePeriod(period)
+	if err != nil || !p.Supported() {
+		return InvalidPeriodError
+	}
+	o := newBackfillOptions(options)
+	topic := fmt.Sprintf("market.%s.kline.%s", symbol, p)
+	start, end := from.Unix(), to.Unix()
+	// 按最短时长分页可以保证每页不超过单次请求的数量限制
+	span := int64(o.pageSize-1) * int64(p.MinDuration()/time.Second)
+
+	var last int64 = -1
+	for first := true; start <= end; first = false {
+		if !first && o.pageInterval > 0 {
+			if err := client.SleepContext(ctx, o.pageInterval); err != nil {
+				return err
+			}
+		}
+		pageEnd := start + span
+		if pageEnd > end {
+			pageEnd = end
+		}
+		ticks, err := m.requestKlinePage(ctx, topic, start, pageEnd, o.retry)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(ticks, func(i, j int) bool { return ticks[i].ID < ticks[j].ID })
+		page := ticks[:0]
+		for _, t := range ticks {
+			id := int64(t.ID)
+			if id < start || id > pageEnd || id <= last {
+				continue
+			}
+			page = append(page, t)
+			last = id
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		start = pageEnd + 1
+	}
+	return nil
+}
+
+// requestKlinePage 请求一页K线历史数据，请求频率超限、超时或连接断开时按重试策略重试
+func (m *Market) requestKlinePage(ctx context.Context, topic string, from, to int64, retry client.RetryPolicy) ([]data_type.KlineTick, error) {
+	params := map[string]interface{}{"from": from, "to": to}
+	for attempt := 1; ; attempt++ {
+		reqCtx, cancel := context.WithTimeout(ctx, m.ReceiveTimeout)
+		rep, err := m.RequestWithParams(reqCtx, topic, params)
+		cancel()
+		if err == nil {
+			raw, err := rep.Get("data").Encode()
+			if err != nil {
+				return nil, err
+			}
+			var ticks []data_type.KlineTick
+			if err := json.Unmarshal(raw, &ticks); err != nil {
+				return nil, err
+			}
+			return ticks, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		retryable := client.IsRateLimited(err) || err == context.DeadlineExceeded || err == ConnectionClosedError
+		if !retryable || attempt >= retry.MaxAttempts {
+			return nil, err
+		}
+		delay := retry.Backoff(attempt)
+		debug.Println("request kline failed", topic, from, to, err, "retry after", delay)
+		if err := client.SleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/market/kline"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+// klineHandler 按from和to返回1分钟K线，超过KlineRequestLimit时返回错误
+func klineHandler(ids *[]int64, mutex *sync.Mutex) markettest.RequestHandler {
+	return func(r *markettest.Request) (string, error) {
+		var from, to int64
+		json.Unmarshal(r.Params["from"], &from)
+		json.Unmarshal(r.Params["to"], &to)
+		mutex.Lock()
+		*ids = append(*ids, from, to)
+		mutex.Unlock()
+		first := (from + 59) / 60 * 60
+		if (to-first)/60+1 > KlineRequestLimit {
+			return "", &markettest.Error{Code: "bad-request", Message: "too many candles"}
+		}
+		var ticks []string
+		// 逆序返回，并附带一根范围之外的K线
+		for id := to / 60 * 60; id >= first; id -= 60 {
+			ticks = append(ticks, fmt.Sprintf(`{"id":%d,"open":1,"close":%d,"low":1,"high":2,"vol":3}`, id, id/60))
+		}
+		ticks = append(ticks, fmt.Sprintf(`{"id":%d,"close":0}`, first-60))
+		return "[" + strings.Join(ticks, ",") + "]", nil
+	}
+}
+
+func TestMarket_KlineHistory(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	var mutex sync.Mutex
+	var ids []int64
+	server.HandleRequest("market.btcusdt.kline.1min", klineHandler(&ids, &mutex))
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	from := time.Unix(1500000030, 0)
+	to := from.Add(1000 * time.Minute)
+	ticks, err := m.KlineHistory(context.Background(), "btcusdt", "1min", from, to, WithPageInterval(0))
+	assert.NoError(t, err)
+	// from不是整分钟，第一根K线从下一分钟开始
+	assert.Len(t, ticks, 1000)
+	assert.Equal(t, uint(1500000060), ticks[0].ID)
+	for i := 1; i < len(ticks); i++ {
+		assert.Equal(t, ticks[i-1].ID+60, ticks[i].ID)
+	}
+	assert.Equal(t, 4, server.Requests("market.btcusdt.kline.1min"))
+	// 各页的时间范围连续且不重叠
+	assert.Equal(t, []int64{1500000030, 1500017970, 1500017971, 1500035911, 1500035912, 1500053852, 1500053853, 1500060030}, ids)
+
+	// 逐页返回，fn返回错误时停止
+	stop := fmt.Errorf("stop")
+	var pages []int
+	err = m.KlineHistoryPages(context.Background(), "btcusdt", "1min", from, to, func(page []data_type.KlineTick) error {
+		pages = append(pages, len(page))
+		if len(pages) == 2 {
+			return stop
+		}
+		return nil
+	}, WithPageSize(100), WithPageInterval(time.Millisecond))
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []int{99, 99}, pages)
+
+	// 周期按kline.ParsePeriod解析，只支持火币提供的周期
+	_, err = m.KlineHistory(context.Background(), "btcusdt", "2min", from, to)
+	assert.Equal(t, kline.InvalidPeriodError, err)
+	_, err = m.KlineHistory(context.Background(), "btcusdt", "1sec", from, to)
+	assert.Equal(t, InvalidPeriodError, err)
+	server.SetResponse("market.btcusdt.kline.60min", "[]")
+	_, err = m.KlineHistory(context.Background(), "btcusdt", "1hour", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.Requests("market.btcusdt.kline.60min"))
+}
+
+func TestMarket_KlineHistory_RateLimit(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	var mutex sync.Mutex
+	var ids []int64
+	handler := klineHandler(&ids, &mutex)
+	limited := 2
+	server.HandleRequest("market.btcusdt.kline.1min", func(r *markettest.Request) (string, error) {
+		if limited > 0 {
+			limited--
+			return "", &markettest.Error{Code: "too-many-request", Message: "too many request"}
+		}
+		return handler(r)
+	})
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	from := time.Unix(1500000000, 0)
+	retry := client.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond}
+	ticks, err := m.KlineHistory(context.Background(), "btcusdt", "1min", from, from.Add(time.Hour), WithPageRetryPolicy(retry))
+	assert.NoError(t, err)
+	assert.Len(t, ticks, 61)
+	assert.Equal(t, 3, server.Requests("market.btcusdt.kline.1min"))
+
+	// 超过最大尝试次数后返回错误
+	limited = 3
+	_, err = m.KlineHistory(context.Background(), "btcusdt", "1min", from, from.Add(time.Hour), WithPageRetryPolicy(retry))
+	assert.True(t, client.IsRateLimited(err), err)
+
+	// 其他错误不重试
+	server.SetError("market.btcusdt.kline.1min", "bad-request", "invalid symbol")
+	_, err = m.KlineHistory(context.Background(), "btcusdt", "1min", from, from.Add(time.Hour), WithPageRetryPolicy(retry))
+	assert.Error(t, err)
+	assert.Equal(t, 7, server.Requests("market.btcusdt.kline.1min"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.KlineHistory(ctx, "btcusdt", "1min", from, from.Add(time.Hour))
+	assert.Equal(t, context.Canceled, err)
+}
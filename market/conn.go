@@ -0,0 +1,263 @@
+package market
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leizongmin/huobiapi/debug"
+)
+
+// ConnConfig 创建Conn的参数
+type ConnConfig struct {
+	// WebSocket入口地址
+	Endpoint string
+	// 建立连接使用的Dialer，为nil时使用websocket.DefaultDialer
+	Dialer *websocket.Dialer
+	// 建立连接时附加的请求头
+	Header http.Header
+	// 断线重连策略
+	ReconnectPolicy ReconnectPolicy
+	// 连接生命周期回调
+	Hooks Hooks
+	// 返回检查心跳使用的毫秒时间戳，为nil时使用本地时钟
+	Now func() int64
+	// 建立连接后、调用Hooks.OnConnected之前调用，用于开始接收消息、发送心跳和鉴权，返回错误时关闭连接并视为连接失败
+	OnOpen func(ws *SafeWebSocket) error
+	// 重连成功后调用，用于重新订阅，返回重新订阅成功的主题
+	Resubscribe func() []string
+	// 放弃重连后、调用Hooks.OnGaveUp之前调用
+	OnGiveUp func()
+}
+
+// Conn 断线后按重连策略自动重连的WebSocket连接，Market和account.Client都基于Conn管理连接、重连和心跳检查
+type Conn struct {
+	// 上次收到心跳的时间戳，使用原子操作读写，放在结构体开头以保证64位对齐
+	lastPing int64
+
+	// ws、autoReconnect和stop由mutex保护
+	ws    *SafeWebSocket
+	mutex sync.Mutex
+	// 执行Close()时关闭，用于中断重连前的等待
+	stop chan struct{}
+	// 掉线后是否自动重连，如果用户主动执行Close()则不自动重连
+	autoReconnect bool
+	// 保证同一时间只有一个goroutine在重连
+	reconnectMutex sync.Mutex
+
+	// 创建后不再修改
+	config ConnConfig
+}
+
+// NewConn 创建Conn，调用Connect()后建立连接
+func NewConn(config ConnConfig) *Conn {
+	if config.Dialer == nil {
+		config.Dialer = websocket.DefaultDialer
+	}
+	if config.Now == nil {
+		config.Now = func() int64 {
+			return time.Now().UnixNano() / int64(time.Millisecond)
+		}
+	}
+	return &Conn{
+		stop:          make(chan struct{}),
+		autoReconnect: true,
+		config:        config,
+	}
+}
+
+// Connect 建立连接，连接过程中执行了Close()时返回ConnectionClosedError
+func (c *Conn) Connect() error {
+	debug.Println("connecting")
+	ws, err := NewSafeWebSocketWithDialer(c.config.Endpoint, c.config.Dialer, c.config.Header)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	if !c.autoReconnect {
+		// 连接过程中被关闭
+		c.mutex.Unlock()
+		ws.Destroy()
+		return ConnectionClosedError
+	}
+	c.ws = ws
+	c.mutex.Unlock()
+	c.Touch()
+
+	if c.config.OnOpen != nil {
+		if err := c.config.OnOpen(ws); err != nil {
+			ws.Destroy()
+			return err
+		}
+	}
+	debug.Println("connected")
+
+	if c.config.Hooks.OnConnected != nil {
+		c.config.Hooks.OnConnected()
+	}
+	return nil
+}
+
+// Current 当前连接
+func (c *Conn) Current() *SafeWebSocket {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ws
+}
+
+// AutoReconnect 是否自动重连，执行Close()或放弃重连后返回false
+func (c *Conn) AutoReconnect() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.autoReconnect
+}
+
+// stopChan 执行Close()时关闭的通道
+func (c *Conn) stopChan() chan struct{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stop
+}
+
+// Touch 记录收到心跳的时间
+func (c *Conn) Touch() {
+	atomic.StoreInt64(&c.lastPing, c.config.Now())
+}
+
+// KeepAlive 每隔interval调用一次ping，并检查上次调用Touch()的时间，超过两个心跳周期时重新连接，ping为nil时只检查
+func (c *Conn) KeepAlive(ws *SafeWebSocket, interval time.Duration, ping func(now int64)) {
+	ws.KeepAlive(interval, func() {
+		t := c.config.Now()
+		if ping != nil {
+			ping(t)
+		}
+
+		tr := time.Duration(t-atomic.LoadInt64(&c.lastPing)) * time.Millisecond
+		if tr >= interval*2 {
+			debug.Println("no ping max delay", tr, interval*2)
+			if c.AutoReconnect() {
+				if err := c.reconnect(ws, HeartbeatTimeoutError); err != nil {
+					debug.Println(err)
+				}
+			}
+		}
+	})
+}
+
+// reconnect 销毁连接old并按重连策略重新连接，然后重新订阅，如果old已经被其他goroutine替换则直接返回
+// cause为断开的原因，为nil时使用old出错的原因
+func (c *Conn) reconnect(old *SafeWebSocket, cause error) error {
+	c.reconnectMutex.Lock()
+	defer c.reconnectMutex.Unlock()
+	if c.Current() != old {
+		return nil
+	}
+	old.Destroy()
+	if cause == nil {
+		cause = old.Err()
+	}
+	hooks := c.config.Hooks
+	if hooks.OnDisconnected != nil {
+		hooks.OnDisconnected(cause)
+	}
+
+	policy := c.config.ReconnectPolicy
+	stop := c.stopChan()
+	start := time.Now()
+	err := cause
+	for attempt := 1; ; attempt++ {
+		if !c.AutoReconnect() {
+			return ConnectionClosedError
+		}
+		delay := policy.Backoff(attempt)
+		if (policy.MaxAttempts > 0 && attempt > policy.MaxAttempts) ||
+			(policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime) {
+			return c.giveUp(err)
+		}
+		if hooks.OnReconnecting != nil {
+			hooks.OnReconnecting(attempt)
+		}
+
+		debug.Println("reconnecting after", delay, "attempt", attempt)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return ConnectionClosedError
+		}
+		if err = c.Connect(); err == nil {
+			break
+		}
+		debug.Println(err)
+	}
+
+	var resubscribed []string
+	if c.config.Resubscribe != nil {
+		resubscribed = c.config.Resubscribe()
+	}
+	if hooks.OnResubscribed != nil {
+		hooks.OnResubscribed(resubscribed)
+	}
+	return nil
+}
+
+// giveUp 停止自动重连，Loop()随之退出
+func (c *Conn) giveUp(err error) error {
+	debug.Println("give up reconnecting", err)
+	c.mutex.Lock()
+	c.autoReconnect = false
+	c.mutex.Unlock()
+	if c.config.OnGiveUp != nil {
+		c.config.OnGiveUp()
+	}
+	if c.config.Hooks.OnGaveUp != nil {
+		c.config.Hooks.OnGaveUp(err)
+	}
+	return err
+}
+
+// Loop 进入循环，连接断开时自动重连，直到执行Close()或放弃重连才退出
+func (c *Conn) Loop() {
+	debug.Println("startLoop")
+	for {
+		ws := c.Current()
+		err := ws.Loop()
+		debug.Println(err)
+		if !c.AutoReconnect() {
+			break
+		}
+		if err := c.reconnect(ws, err); err != nil {
+			debug.Println(err)
+		}
+	}
+	debug.Println("endLoop")
+}
+
+// ReConnect 重新连接，按重连策略重试，之前执行过Close()或放弃重连时恢复自动重连
+func (c *Conn) ReConnect() error {
+	debug.Println("reconnect")
+	c.mutex.Lock()
+	if !c.autoReconnect {
+		c.autoReconnect = true
+		c.stop = make(chan struct{})
+	}
+	c.mutex.Unlock()
+	return c.reconnect(c.Current(), nil)
+}
+
+// Close 关闭连接，之后不再自动重连
+func (c *Conn) Close() error {
+	debug.Println("close")
+	c.mutex.Lock()
+	if c.autoReconnect {
+		c.autoReconnect = false
+		close(c.stop)
+	}
+	ws := c.ws
+	c.mutex.Unlock()
+	return ws.Destroy()
+}
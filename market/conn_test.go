@@ -0,0 +1,69 @@
+package market
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConn(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	// 服务器不发送ping，由心跳检查触发重连
+	server.SetPingInterval(0)
+
+	events := make(chan string, 100)
+	opened := 0
+	c := NewConn(ConnConfig{
+		Endpoint:        server.URL,
+		ReconnectPolicy: ReconnectPolicy{Delay: 10 * time.Millisecond},
+		Hooks: Hooks{
+			OnConnected: func() { events <- "connected" },
+			OnDisconnected: func(err error) {
+				if err != HeartbeatTimeoutError {
+					err = fmt.Errorf("closed")
+				}
+				events <- fmt.Sprint("disconnected ", err)
+			},
+			OnResubscribed: func(topics []string) { events <- fmt.Sprint("resubscribed ", topics) },
+		},
+		OnOpen: func(ws *SafeWebSocket) error {
+			opened++
+			if opened == 2 {
+				return fmt.Errorf("auth failed")
+			}
+			return nil
+		},
+		Resubscribe: func() []string { return []string{"topic"} },
+	})
+	assert.NoError(t, c.Connect())
+	assert.Equal(t, "connected", <-events)
+	assert.True(t, c.AutoReconnect())
+
+	loopDone := make(chan struct{})
+	go func() {
+		c.Loop()
+		close(loopDone)
+	}()
+
+	// OnOpen返回错误时视为连接失败，按重连策略继续重试
+	server.CloseConnections()
+	assert.Equal(t, "disconnected closed", <-events)
+	assert.Equal(t, "connected", <-events)
+	assert.Equal(t, "resubscribed [topic]", <-events)
+	assert.Equal(t, 3, opened)
+	assert.Equal(t, 3, server.Connected())
+
+	// 超过两个心跳周期没有调用Touch()时重新连接
+	c.KeepAlive(c.Current(), 20*time.Millisecond, nil)
+	assert.Equal(t, "disconnected "+HeartbeatTimeoutError.Error(), <-events)
+	assert.Equal(t, "connected", <-events)
+
+	c.Close()
+	<-loopDone
+	assert.False(t, c.AutoReconnect())
+	assert.Equal(t, ConnectionClosedError, c.Connect())
+}
@@ -0,0 +1,237 @@
+// Package kline 根据逐笔成交生成K线，以及将K线合并为更长的周期
+// 可以生成火币不提供的周期，也可以配合market.KlineHistory补齐断线期间缺失的K线
+// K线的ID为周期开始时间的Unix时间戳（秒），Amount为成交量，Vol为成交额，Count为成交笔数
+package kline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/leizongmin/huobiapi/data_type"
+)
+
+// DefaultLocation 默认划分日、周和月的时区，与火币K线一致为UTC+8
+var DefaultLocation = time.FixedZone("UTC+8", 8*3600)
+
+type options struct {
+	location *time.Location
+	fillGaps bool
+}
+
+// Option 生成K线的选项
+type Option func(o *options)
+
+// WithLocation 设置划分日、周和月的时区，默认为DefaultLocation
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithFillGaps 没有成交或没有K线的周期也生成一根K线，开盘价、收盘价、最高价和最低价均为上一根K线的收盘价
+func WithFillGaps() Option {
+	return func(o *options) {
+		o.fillGaps = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{location: DefaultLocation}
+	for _, fn := range opts {
+		fn(o)
+	}
+	if o.location == nil {
+		o.location = DefaultLocation
+	}
+	return o
+}
+
+// Builder 根据按时间顺序收到的逐笔成交生成K线，不能在多个goroutine中同时使用
+type Builder struct {
+	period  Period
+	options *options
+	candle  data_type.KlineTick
+	// 当前K线的时间范围[start, end)
+	start time.Time
+	end   time.Time
+	open  bool
+}
+
+// NewBuilder 创建生成period周期K线的Builder
+func NewBuilder(period Period, options ...Option) *Builder {
+	return &Builder{period: period, options: newOptions(options)}
+}
+
+// AddTrade 加入trade.detail推送的一组成交，返回已经结束的K线
+func (b *Builder) AddTrade(trade *data_type.Trade) []data_type.KlineTick {
+	items := append([]data_type.TradeItem(nil), trade.Tick.Data...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Ts < items[j].Ts })
+	var closed []data_type.KlineTick
+	for _, item := range items {
+		closed = append(closed, b.Add(item)...)
+	}
+	return closed
+}
+
+// Add 加入一笔成交，成交时间进入下一个周期时返回已经结束的K线，早于当前K线开始时间的成交被忽略
+func (b *Builder) Add(item data_type.TradeItem) []data_type.KlineTick {
+	ts := time.Unix(0, int64(item.Ts)*int64(time.Millisecond))
+	if b.open && ts.Before(b.start) {
+		return nil
+	}
+	closed := b.Advance(ts)
+	if !b.open {
+		b.begin(b.period.Start(ts, b.options.location), item.Price)
+	}
+	if b.candle.Count == 0 {
+		// 补齐的K线收到第一笔成交时以成交价开盘
+		b.candle.Open, b.candle.High, b.candle.Low = item.Price, item.Price, item.Price
+	}
+	if item.Price > b.candle.High {
+		b.candle.High = item.Price
+	}
+	if item.Price < b.candle.Low {
+		b.candle.Low = item.Price
+	}
+	b.candle.Close = item.Price
+	b.candle.Amount += item.Amount
+	b.candle.Vol += item.Amount * item.Price
+	b.candle.Count++
+	return closed
+}
+
+// Advance 时间到达now时结束当前K线并返回，没有成交时也可以定时调用以及时得到结束的K线
+// 设置了WithFillGaps时同时返回now之前没有成交的周期
+func (b *Builder) Advance(now time.Time) []data_type.KlineTick {
+	if !b.open || now.Before(b.end) {
+		return nil
+	}
+	closed := []data_type.KlineTick{b.candle}
+	last := b.candle.Close
+	b.open = false
+	if b.options.fillGaps {
+		start := b.end
+		for end := b.period.Next(start); !now.Before(end); end = b.period.Next(end) {
+			closed = append(closed, flat(start, last))
+			start = end
+		}
+		// 下一笔成交之前保持为没有成交的K线
+		b.begin(start, last)
+	}
+	return closed
+}
+
+// Current 当前尚未结束的K线
+func (b *Builder) Current() (data_type.KlineTick, bool) {
+	return b.candle, b.open
+}
+
+// Flush 结束并返回当前K线，之后收到的成交开始新的K线
+func (b *Builder) Flush() (data_type.KlineTick, bool) {
+	if !b.open {
+		return data_type.KlineTick{}, false
+	}
+	b.open = false
+	return b.candle, true
+}
+
+// begin 开始一根从start开始、各价格均为price的K线
+func (b *Builder) begin(start time.Time, price float64) {
+	b.start = start
+	b.end = b.period.Next(start)
+	b.candle = flat(start, price)
+	b.open = true
+}
+
+// Resample 将K线合并为period周期的K线，period必须是原周期的整数倍
+// 输入不需要有序，ID相同的K线保留后出现的一根；返回按时间升序排列的K线，最后一根可能尚未结束
+func Resample(ticks []data_type.KlineTick, period Period, options ...Option) []data_type.KlineTick {
+	o := newOptions(options)
+	var ret []data_type.KlineTick
+	var end time.Time
+	for _, t := range Merge(ticks) {
+		ts := time.Unix(int64(t.ID), 0)
+		if len(ret) > 0 && ts.Before(end) {
+			c := &ret[len(ret)-1]
+			if t.High > c.High {
+				c.High = t.High
+			}
+			if t.Low < c.Low {
+				c.Low = t.Low
+			}
+			c.Close = t.Close
+			c.Amount += t.Amount
+			c.Vol += t.Vol
+			c.Count += t.Count
+			continue
+		}
+
+		start := period.Start(ts, o.location)
+		if o.fillGaps && len(ret) > 0 {
+			last := ret[len(ret)-1].Close
+			for s := end; s.Before(start); s = period.Next(s) {
+				ret = append(ret, flat(s, last))
+			}
+		}
+		end = period.Next(start)
+		t.ID = uint(start.Unix())
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+// Merge 合并多组K线，返回按时间升序排列的K线，ID相同时保留后面的一组中的K线
+// 可用于将断线期间补齐的历史K线与实时推送的K线合并
+func Merge(series ...[]data_type.KlineTick) []data_type.KlineTick {
+	var all []data_type.KlineTick
+	for _, s := range series {
+		all = append(all, s...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	ret := all[:0]
+	for _, t := range all {
+		if len(ret) > 0 && ret[len(ret)-1].ID == t.ID {
+			ret[len(ret)-1] = t
+			continue
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+// Gap 缺失K线的时间范围[From, To]，From为第一根缺失K线的开始时间，To为最后一根缺失K线的开始时间
+type Gap struct {
+	From time.Time
+	To   time.Time
+}
+
+// Gaps 查找period周期的K线中缺失的部分，ticks必须按时间升序排列，返回的时间范围可以直接用于market.KlineHistory
+func Gaps(ticks []data_type.KlineTick, period Period, options ...Option) []Gap {
+	o := newOptions(options)
+	var gaps []Gap
+	for i := 1; i < len(ticks); i++ {
+		prev := period.Start(time.Unix(int64(ticks[i-1].ID), 0), o.location)
+		start := period.Next(prev)
+		cur := time.Unix(int64(ticks[i].ID), 0)
+		if !start.Before(cur) {
+			continue
+		}
+		gap := Gap{From: start}
+		for s := start; s.Before(cur); s = period.Next(s) {
+			gap.To = s
+		}
+		gaps = append(gaps, gap)
+	}
+	return gaps
+}
+
+// flat 从start开始、没有成交、各价格均为price的K线
+func flat(start time.Time, price float64) data_type.KlineTick {
+	return data_type.KlineTick{
+		ID:    uint(start.Unix()),
+		Open:  price,
+		Close: price,
+		High:  price,
+		Low:   price,
+	}
+}
@@ -0,0 +1,172 @@
+package kline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/stretchr/testify/assert"
+)
+
+// base 2019-09-04 00:00:00 UTC+8
+var base = time.Date(2019, 9, 4, 0, 0, 0, 0, DefaultLocation)
+
+func trade(offset time.Duration, price, amount float64) data_type.TradeItem {
+	return data_type.TradeItem{
+		Ts:     uint(base.Add(offset).UnixNano() / int64(time.Millisecond)),
+		Price:  price,
+		Amount: amount,
+	}
+}
+
+func candle(offset time.Duration, open, high, low, close, amount float64) data_type.KlineTick {
+	return data_type.KlineTick{
+		ID:     uint(base.Add(offset).Unix()),
+		Open:   open,
+		High:   high,
+		Low:    low,
+		Close:  close,
+		Amount: amount,
+		Vol:    amount * close,
+		Count:  1,
+	}
+}
+
+func ids(ticks []data_type.KlineTick) []time.Duration {
+	ret := make([]time.Duration, len(ticks))
+	for i, t := range ticks {
+		ret[i] = time.Unix(int64(t.ID), 0).Sub(base)
+	}
+	return ret
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(Period1Min)
+	_, ok := b.Current()
+	assert.False(t, ok)
+
+	assert.Empty(t, b.Add(trade(10*time.Second, 10, 1)))
+	assert.Empty(t, b.Add(trade(20*time.Second, 12, 2)))
+	assert.Empty(t, b.Add(trade(30*time.Second, 9, 1)))
+	assert.Empty(t, b.Add(trade(59*time.Second, 11, 1)))
+	c, ok := b.Current()
+	assert.True(t, ok)
+	assert.Equal(t, data_type.KlineTick{ID: uint(base.Unix()), Open: 10, High: 12, Low: 9, Close: 11, Amount: 5, Vol: 54, Count: 4}, c)
+
+	// 进入下一个周期时返回结束的K线，没有成交的周期不生成K线
+	closed := b.Add(trade(3*time.Minute+time.Second, 13, 1))
+	assert.Equal(t, []data_type.KlineTick{c}, closed)
+	// 早于当前K线的成交被忽略
+	assert.Empty(t, b.Add(trade(time.Minute, 1, 1)))
+	c, _ = b.Current()
+	assert.Equal(t, uint(base.Add(3*time.Minute).Unix()), c.ID)
+	assert.Equal(t, 13.0, c.Low)
+
+	assert.Empty(t, b.Advance(base.Add(3*time.Minute+30*time.Second)))
+	closed = b.Advance(base.Add(4 * time.Minute))
+	assert.Equal(t, []data_type.KlineTick{c}, closed)
+	_, ok = b.Current()
+	assert.False(t, ok)
+
+	// 一组成交按时间排序后加入
+	var tr data_type.Trade
+	tr.Tick.Data = []data_type.TradeItem{trade(5*time.Minute+2*time.Second, 15, 1), trade(5*time.Minute+time.Second, 14, 1)}
+	assert.Empty(t, b.AddTrade(&tr))
+	c, ok = b.Flush()
+	assert.True(t, ok)
+	assert.Equal(t, 14.0, c.Open)
+	assert.Equal(t, 15.0, c.Close)
+	_, ok = b.Flush()
+	assert.False(t, ok)
+}
+
+func TestBuilder_FillGaps(t *testing.T) {
+	b := NewBuilder(Period5Min, WithFillGaps())
+	b.Add(trade(time.Minute, 10, 1))
+	b.Add(trade(2*time.Minute, 11, 1))
+
+	// 没有成交的周期以上一根K线的收盘价补齐
+	closed := b.Add(trade(17*time.Minute, 9, 1))
+	assert.Equal(t, []time.Duration{0, 5 * time.Minute, 10 * time.Minute}, ids(closed))
+	assert.Equal(t, data_type.KlineTick{ID: closed[1].ID, Open: 11, High: 11, Low: 11, Close: 11}, closed[1])
+	c, _ := b.Current()
+	assert.Equal(t, data_type.KlineTick{ID: uint(base.Add(15 * time.Minute).Unix()), Open: 9, High: 9, Low: 9, Close: 9, Amount: 1, Vol: 9, Count: 1}, c)
+
+	// 定时调用时即使没有成交也会结束K线
+	closed = b.Advance(base.Add(26 * time.Minute))
+	assert.Equal(t, []time.Duration{15 * time.Minute, 20 * time.Minute}, ids(closed))
+	c, ok := b.Current()
+	assert.True(t, ok)
+	assert.Equal(t, uint(0), c.Count)
+	assert.Equal(t, 9.0, c.Open)
+	b.Add(trade(27*time.Minute, 8, 1))
+	c, _ = b.Current()
+	assert.Equal(t, uint(base.Add(25*time.Minute).Unix()), c.ID)
+	assert.Equal(t, 8.0, c.Open)
+	assert.Equal(t, 8.0, c.High)
+}
+
+func TestResample(t *testing.T) {
+	ticks := []data_type.KlineTick{
+		candle(7*time.Minute, 12, 13, 11, 12, 1),
+		candle(0, 10, 11, 9, 10, 1),
+		candle(time.Minute, 10, 12, 10, 11, 2),
+		candle(4*time.Minute, 11, 11, 8, 9, 1),
+		candle(5*time.Minute, 9, 10, 9, 10, 1),
+		// ID重复时保留后出现的一根
+		candle(7*time.Minute, 12, 14, 11, 13, 1),
+		candle(21*time.Minute, 13, 13, 13, 13, 1),
+	}
+	ret := Resample(ticks, Period5Min)
+	assert.Equal(t, []time.Duration{0, 5 * time.Minute, 20 * time.Minute}, ids(ret))
+	assert.Equal(t, data_type.KlineTick{ID: uint(base.Unix()), Open: 10, High: 12, Low: 8, Close: 9, Amount: 4, Vol: 10 + 22 + 9, Count: 3}, ret[0])
+	assert.Equal(t, data_type.KlineTick{ID: uint(base.Add(5 * time.Minute).Unix()), Open: 9, High: 14, Low: 9, Close: 13, Amount: 2, Vol: 10 + 13, Count: 2}, ret[1])
+
+	ret = Resample(ticks, Period5Min, WithFillGaps())
+	assert.Equal(t, []time.Duration{0, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 20 * time.Minute}, ids(ret))
+	assert.Equal(t, 13.0, ret[2].Open)
+	assert.Equal(t, uint(0), ret[3].Count)
+
+	// 按时区划分日K线
+	hourly := []data_type.KlineTick{
+		candle(-2*time.Hour, 1, 1, 1, 1, 1),
+		candle(time.Hour, 2, 2, 2, 2, 1),
+		candle(13*time.Hour, 3, 3, 3, 3, 1),
+	}
+	assert.Equal(t, []time.Duration{-24 * time.Hour, 0}, ids(Resample(hourly, Period1Day)))
+	// UTC的0点为UTC+8的8点
+	assert.Equal(t, []time.Duration{-16 * time.Hour, 8 * time.Hour}, ids(Resample(hourly, Period1Day, WithLocation(time.UTC))))
+	assert.Equal(t, []time.Duration{-2 * 24 * time.Hour}, ids(Resample(hourly, Period1Week)))
+	assert.Equal(t, []time.Duration{-3 * 24 * time.Hour}, ids(Resample(hourly, Period1Mon)))
+
+	assert.Empty(t, Resample(nil, Period1Day))
+}
+
+func TestMergeAndGaps(t *testing.T) {
+	live := []data_type.KlineTick{
+		candle(0, 1, 1, 1, 1, 1),
+		candle(time.Minute, 2, 2, 2, 2, 1),
+		candle(5*time.Minute, 3, 3, 3, 3, 1),
+		candle(6*time.Minute, 4, 4, 4, 4, 1),
+		candle(8*time.Minute, 5, 5, 5, 5, 1),
+	}
+	gaps := Gaps(live, Period1Min)
+	assert.Equal(t, []Gap{
+		{From: base.Add(2 * time.Minute), To: base.Add(4 * time.Minute)},
+		{From: base.Add(7 * time.Minute), To: base.Add(7 * time.Minute)},
+	}, gaps)
+
+	// 补齐的历史K线覆盖实时推送中未结束的K线
+	history := []data_type.KlineTick{
+		candle(time.Minute, 2, 3, 2, 3, 2),
+		candle(2*time.Minute, 6, 6, 6, 6, 1),
+		candle(3*time.Minute, 7, 7, 7, 7, 1),
+		candle(4*time.Minute, 8, 8, 8, 8, 1),
+		candle(7*time.Minute, 9, 9, 9, 9, 1),
+	}
+	merged := Merge(live, history)
+	assert.Len(t, merged, 9)
+	assert.Empty(t, Gaps(merged, Period1Min))
+	assert.Equal(t, 3.0, merged[1].Close)
+	assert.Equal(t, 1.0, live[0].Close)
+}
@@ -0,0 +1,167 @@
+package kline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// InvalidPeriodError 无法解析或不支持的K线周期，market.KlineHistory也返回此错误
+var InvalidPeriodError = fmt.Errorf("invalid kline period")
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// Period K线周期，Duration和Months必须有且只有一个大于0
+// 小于1天的周期从每天0点开始划分，整天的周期从1970-01-01开始划分，整周的周期从周一开始，月周期从1月开始
+type Period struct {
+	// 固定时长的周期
+	Duration time.Duration
+	// 按自然月计算的周期的月数
+	Months int
+}
+
+// 火币支持的K线周期，String()返回的名称与client.KlinePeriod*一致
+var (
+	Period1Min  = Period{Duration: time.Minute}
+	Period5Min  = Period{Duration: 5 * time.Minute}
+	Period15Min = Period{Duration: 15 * time.Minute}
+	Period30Min = Period{Duration: 30 * time.Minute}
+	Period60Min = Period{Duration: time.Hour}
+	Period4Hour = Period{Duration: 4 * time.Hour}
+	Period1Day  = Period{Duration: day}
+	Period1Week = Period{Duration: week}
+	Period1Mon  = Period{Months: 1}
+	Period1Year = Period{Months: 12}
+)
+
+// maxMonths 最大的月数，保证MinDuration()不会溢出
+const maxMonths = math.MaxInt64 / int64(28*day)
+
+// ParsePeriod 解析K线周期，格式为数量加单位，单位可选min、hour、day、week、mon和year，例如1min、60min、4hour、3day和1mon
+// 周期超出time.Duration的范围时返回InvalidPeriodError
+func ParsePeriod(s string) (Period, error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil || n <= 0 {
+		return Period{}, InvalidPeriodError
+	}
+	var unit time.Duration
+	months := int64(0)
+	switch s[i:] {
+	case "min":
+		unit = time.Minute
+	case "hour":
+		unit = time.Hour
+	case "day":
+		unit = day
+	case "week":
+		unit = week
+	case "mon":
+		months = 1
+	case "year":
+		months = 12
+	default:
+		return Period{}, InvalidPeriodError
+	}
+	if months > 0 {
+		if n > maxMonths/months {
+			return Period{}, InvalidPeriodError
+		}
+		return Period{Months: int(n * months)}, nil
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return Period{}, InvalidPeriodError
+	}
+	return Period{Duration: time.Duration(n) * unit}, nil
+}
+
+// Supported 是否为火币K线接口支持的周期
+func (p Period) Supported() bool {
+	switch p {
+	case Period1Min, Period5Min, Period15Min, Period30Min, Period60Min, Period4Hour, Period1Day, Period1Week, Period1Mon, Period1Year:
+		return true
+	}
+	return false
+}
+
+// MinDuration 周期的最短时长，月按28天计算
+func (p Period) MinDuration() time.Duration {
+	if p.Months > 0 {
+		return time.Duration(p.Months) * 28 * day
+	}
+	return p.Duration
+}
+
+// String 周期名称，1小时按火币的命名返回60min
+func (p Period) String() string {
+	switch {
+	case p.Months > 0 && p.Months%12 == 0:
+		return fmt.Sprintf("%dyear", p.Months/12)
+	case p.Months > 0:
+		return fmt.Sprintf("%dmon", p.Months)
+	case p.Duration%week == 0:
+		return fmt.Sprintf("%dweek", p.Duration/week)
+	case p.Duration%day == 0:
+		return fmt.Sprintf("%dday", p.Duration/day)
+	case p.Duration%time.Hour == 0 && p.Duration != time.Hour:
+		return fmt.Sprintf("%dhour", p.Duration/time.Hour)
+	}
+	return fmt.Sprintf("%dmin", p.Duration/time.Minute)
+}
+
+// Start t所在周期的开始时间，按loc所在时区划分日、周和月
+func (p Period) Start(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch {
+	case p.Months > 0:
+		n := y*12 + int(m) - 1
+		n -= mod(n, p.Months)
+		return time.Date(n/12, time.Month(n%12+1), 1, 0, 0, 0, 0, loc)
+	case p.Duration%day == 0:
+		days := int(p.Duration / day)
+		n := int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second))
+		if p.Duration%week == 0 {
+			// 1970-01-01是周四，从1969-12-29周一开始划分
+			n -= mod(n+3, days)
+		} else {
+			n -= mod(n, days)
+		}
+		return time.Date(1970, 1, 1+n, 0, 0, 0, 0, loc)
+	}
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	elapsed := t.Sub(midnight)
+	return midnight.Add(elapsed - elapsed%p.Duration)
+}
+
+// Next start所在周期的下一个周期的开始时间，start必须是Start返回的时间
+// 小于1天的周期在每天结束时截断
+func (p Period) Next(start time.Time) time.Time {
+	y, m, d := start.Date()
+	switch {
+	case p.Months > 0:
+		return start.AddDate(0, p.Months, 0)
+	case p.Duration%day == 0:
+		return time.Date(y, m, d+int(p.Duration/day), 0, 0, 0, 0, start.Location())
+	}
+	next := start.Add(p.Duration)
+	if midnight := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location()); next.After(midnight) {
+		return midnight
+	}
+	return next
+}
+
+func mod(a, b int) int {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
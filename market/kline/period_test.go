@@ -0,0 +1,103 @@
+package kline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePeriod(t *testing.T) {
+	for s, p := range map[string]Period{
+		"1min":  Period1Min,
+		"15min": Period15Min,
+		"60min": Period60Min,
+		"1hour": Period60Min,
+		"4hour": Period4Hour,
+		"3day":  {Duration: 3 * day},
+		"1week": Period1Week,
+		"1mon":  Period1Mon,
+		"3mon":  {Months: 3},
+		"1year": Period1Year,
+	} {
+		v, err := ParsePeriod(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, p, v, s)
+	}
+	invalid := []string{
+		"", "min", "0min", "1sec", "-1day",
+		// 超出time.Duration的范围
+		"1125899906842624day", "9223372036854775807min", "99999999999999999999min", "9223372036854775807year",
+	}
+	for _, s := range invalid {
+		_, err := ParsePeriod(s)
+		assert.Equal(t, InvalidPeriodError, err, s)
+	}
+
+	assert.Equal(t, "60min", Period60Min.String())
+	assert.Equal(t, "4hour", Period4Hour.String())
+	assert.Equal(t, "90min", Period{Duration: 90 * time.Minute}.String())
+	assert.Equal(t, "2week", Period{Duration: 2 * week}.String())
+	assert.Equal(t, "1mon", Period1Mon.String())
+	assert.Equal(t, "1year", Period1Year.String())
+
+	// 火币支持的周期与client.KlinePeriod*的名称一致
+	supported := []string{
+		client.KlinePeriod1Min, client.KlinePeriod5Min, client.KlinePeriod15Min, client.KlinePeriod30Min, client.KlinePeriod60Min,
+		client.KlinePeriod4Hour, client.KlinePeriod1Day, client.KlinePeriod1Week, client.KlinePeriod1Mon, client.KlinePeriod1Year,
+	}
+	for _, s := range supported {
+		p, err := ParsePeriod(s)
+		assert.NoError(t, err, s)
+		assert.True(t, p.Supported(), s)
+		assert.Equal(t, s, p.String())
+	}
+	assert.False(t, Period{Duration: 2 * time.Minute}.Supported())
+	assert.False(t, Period{Months: 3}.Supported())
+
+	assert.Equal(t, 4*time.Hour, Period4Hour.MinDuration())
+	assert.Equal(t, 28*day, Period1Mon.MinDuration())
+	assert.Equal(t, 12*28*day, Period1Year.MinDuration())
+}
+
+func TestPeriod_Start(t *testing.T) {
+	utc8 := DefaultLocation
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		ny = time.FixedZone("EST", -5*3600)
+	}
+	// 2019-09-04 周三 01:37:20 UTC
+	ts := time.Date(2019, 9, 4, 1, 37, 20, 0, time.UTC)
+	cases := []struct {
+		period Period
+		loc    *time.Location
+		start  time.Time
+		next   time.Time
+	}{
+		{Period5Min, utc8, time.Date(2019, 9, 4, 9, 35, 0, 0, utc8), time.Date(2019, 9, 4, 9, 40, 0, 0, utc8)},
+		{Period4Hour, utc8, time.Date(2019, 9, 4, 8, 0, 0, 0, utc8), time.Date(2019, 9, 4, 12, 0, 0, 0, utc8)},
+		{Period4Hour, time.UTC, time.Date(2019, 9, 4, 0, 0, 0, 0, time.UTC), time.Date(2019, 9, 4, 4, 0, 0, 0, time.UTC)},
+		// 不能整除1天的周期在每天结束时截断
+		{Period{Duration: 7 * time.Hour}, utc8, time.Date(2019, 9, 4, 7, 0, 0, 0, utc8), time.Date(2019, 9, 4, 14, 0, 0, 0, utc8)},
+		{Period1Day, utc8, time.Date(2019, 9, 4, 0, 0, 0, 0, utc8), time.Date(2019, 9, 5, 0, 0, 0, 0, utc8)},
+		{Period1Day, ny, time.Date(2019, 9, 3, 0, 0, 0, 0, ny), time.Date(2019, 9, 4, 0, 0, 0, 0, ny)},
+		{Period1Week, utc8, time.Date(2019, 9, 2, 0, 0, 0, 0, utc8), time.Date(2019, 9, 9, 0, 0, 0, 0, utc8)},
+		{Period1Mon, utc8, time.Date(2019, 9, 1, 0, 0, 0, 0, utc8), time.Date(2019, 10, 1, 0, 0, 0, 0, utc8)},
+		{Period1Mon, ny, time.Date(2019, 9, 1, 0, 0, 0, 0, ny), time.Date(2019, 10, 1, 0, 0, 0, 0, ny)},
+		{Period{Months: 3}, utc8, time.Date(2019, 7, 1, 0, 0, 0, 0, utc8), time.Date(2019, 10, 1, 0, 0, 0, 0, utc8)},
+		{Period1Year, utc8, time.Date(2019, 1, 1, 0, 0, 0, 0, utc8), time.Date(2020, 1, 1, 0, 0, 0, 0, utc8)},
+	}
+	for _, c := range cases {
+		start := c.period.Start(ts, c.loc)
+		assert.True(t, c.start.Equal(start), "%s %s: %s", c.period, c.loc, start)
+		next := c.period.Next(start)
+		assert.True(t, c.next.Equal(next), "%s %s: %s", c.period, c.loc, next)
+	}
+
+	// 与火币1day K线的ID一致
+	assert.Equal(t, int64(1499184000), Period1Day.Start(time.Unix(1499200000, 0), DefaultLocation).Unix())
+	// 按周划分时从1969-12-29周一开始
+	assert.Equal(t, time.Date(1969, 12, 29, 0, 0, 0, 0, time.UTC), Period1Week.Start(time.Date(1970, 1, 1, 12, 0, 0, 0, time.UTC), time.UTC))
+	assert.Equal(t, time.Date(1970, 1, 12, 0, 0, 0, 0, time.UTC), Period{Duration: 2 * week}.Start(time.Date(1970, 1, 25, 12, 0, 0, 0, time.UTC), time.UTC))
+}
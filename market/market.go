@@ -1,219 +1,280 @@
 package market
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
-	"math"
-
 	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/client"
 	"github.com/leizongmin/huobiapi/debug"
-	"sync"
 )
 
-// Endpoint 行情的Websocket入口
+// Endpoint 行情的Websocket入口，可通过WithEndpoint为每个Market实例单独指定
 var Endpoint = "wss://api.huobi.pro/ws"
 
 // ConnectionClosedError Websocket未连接错误
 var ConnectionClosedError = fmt.Errorf("websocket connection closed")
 
+// HeartbeatTimeoutError 超过两个心跳周期没有收到ping或pong
+var HeartbeatTimeoutError = fmt.Errorf("heartbeat timeout")
+
 type wsOperation struct {
 	cmd  string
 	data interface{}
 }
 
 type Market struct {
-	ws *SafeWebSocket
-
-	listeners         map[string]Listener
-	listenerMutex     sync.Mutex
-	subscribedTopic   map[string]bool
-	subscribeResultCb map[string]jsonChan
-	requestResultCb   map[string]jsonChan
-
-	// 掉线后是否自动重连，如果用户主动执行Close()则不自动重连
-	autoReconnect bool
-
-	// 上次接收到的ping时间戳
-	lastPing int64
-
-	// 主动发送心跳的时间间隔，默认5秒
+	// 连接、重连和心跳检查
+	conn *Conn
+
+	// listeners、subscribedTopic和streams由listenerMutex保护
+	listeners     map[string][]*ListenerHandle
+	listenerMutex sync.Mutex
+	// 已发送订阅指令的主题，订阅结果返回之前添加的监听器等待同一个结果
+	subscribedTopic map[string]*subscribeResult
+	streams         map[*Subscription]struct{}
+
+	subscribeResultCb   map[string]jsonChan
+	unsubscribeResultCb map[string]jsonChan
+	requestResultCb     map[string]jsonChan
+	resultCbMutex       sync.Mutex
+
+	// 主动发送心跳的时间间隔，默认5秒，修改后在下次连接时生效
 	HeartbeatInterval time.Duration
-	// 接收消息超时时间，默认10秒
+	// 等待订阅、取消订阅和请求结果的超时时间，默认10秒，不带Context的方法使用此超时
 	ReceiveTimeout time.Duration
+	// 计算心跳时间戳使用的时钟，默认为本地时钟，可设置为client.TimeSync以避免本地时间偏差
+	Clock client.Clock
+}
+
+// subscribeResult 一次订阅指令的结果，done关闭后err有效
+type subscribeResult struct {
+	done chan struct{}
+	err  error
+	// 等待结果期间添加的监听器，订阅失败时全部移除
+	handles []*ListenerHandle
 }
 
 // Listener 订阅事件监听器
 type Listener = func(topic string, json *simplejson.Json)
 
-// NewMarket 创建Market实例
+// ListenerHandle 监听器句柄，用于单独移除某个监听器
+type ListenerHandle struct {
+	market   *Market
+	topic    string
+	listener Listener
+}
+
+// Topic 监听的主题
+func (h *ListenerHandle) Topic() string {
+	return h.topic
+}
+
+// Unsubscribe 移除此监听器，主题的最后一个监听器移除后发送取消订阅指令并等待结果，最多等待ReceiveTimeout
+// 不能在监听器中调用，否则会因为无法处理取消订阅结果而一直等待到超时，监听器已经移除，不再收到消息
+func (h *ListenerHandle) Unsubscribe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.market.ReceiveTimeout)
+	defer cancel()
+	return h.UnsubscribeWithContext(ctx)
+}
+
+// UnsubscribeWithContext 移除此监听器，主题的最后一个监听器移除后发送取消订阅指令，ctx取消或超时时停止等待结果
+func (h *ListenerHandle) UnsubscribeWithContext(ctx context.Context) error {
+	return h.market.removeListener(ctx, h)
+}
+
+// NewMarket 创建Market实例，连接到Endpoint
 func NewMarket() (m *Market, err error) {
+	return NewMarketWithOptions()
+}
+
+// NewMarketWithOptions 创建Market实例，可通过options指定入口地址、Dialer、请求头、心跳间隔和重连策略等
+func NewMarketWithOptions(options ...Option) (m *Market, err error) {
+	o := newOptions(options)
 	m = &Market{
-		HeartbeatInterval: 5 * time.Second,
-		ReceiveTimeout:    10 * time.Second,
-		ws:                nil,
-		autoReconnect:     true,
-		listeners:         make(map[string]Listener),
-		subscribeResultCb: make(map[string]jsonChan),
-		requestResultCb:   make(map[string]jsonChan),
-		subscribedTopic:   make(map[string]bool),
+		HeartbeatInterval:   o.heartbeatInterval,
+		ReceiveTimeout:      o.receiveTimeout,
+		Clock:               o.clock,
+		listeners:           make(map[string][]*ListenerHandle),
+		subscribeResultCb:   make(map[string]jsonChan),
+		unsubscribeResultCb: make(map[string]jsonChan),
+		requestResultCb:     make(map[string]jsonChan),
+		subscribedTopic:     make(map[string]*subscribeResult),
+		streams:             make(map[*Subscription]struct{}),
 	}
 
-	if err := m.connect(); err != nil {
+	m.conn = NewConn(ConnConfig{
+		Endpoint:        o.endpoint,
+		Dialer:          o.dialer,
+		Header:          o.header,
+		ReconnectPolicy: o.reconnect,
+		Hooks:           o.hooks,
+		Now: func() int64 {
+			return getUinxMillisecond(m.Clock)
+		},
+		OnOpen:      m.open,
+		Resubscribe: m.resubscribe,
+		OnGiveUp:    m.closeStreams,
+	})
+	if err := m.conn.Connect(); err != nil {
 		return nil, err
 	}
 
 	return m, nil
 }
 
-// connect 连接
-func (m *Market) connect() error {
-	debug.Println("connecting")
-	ws, err := NewSafeWebSocket(Endpoint)
-	if err != nil {
-		return err
-	}
-	m.ws = ws
-	m.lastPing = getUinxMillisecond()
-	debug.Println("connected")
-
-	m.handleMessageLoop()
-	m.keepAlive()
-
+// open 建立连接后开始处理消息并发送心跳
+func (m *Market) open(ws *SafeWebSocket) error {
+	m.handleMessageLoop(ws)
+	m.conn.KeepAlive(ws, m.HeartbeatInterval, func(now int64) {
+		m.sendMessageTo(ws, pingData{Ping: now})
+	})
 	return nil
 }
 
-// reconnect 重新连接
-func (m *Market) reconnect() error {
-	debug.Println("reconnecting after 1s")
-	time.Sleep(time.Second)
-
-	if err := m.connect(); err != nil {
-		debug.Println(err)
-		return err
-	}
-
-	// 重新订阅
+// resubscribe 重连后重新订阅所有仍在监听的主题，返回重新订阅成功的主题
+func (m *Market) resubscribe() []string {
 	m.listenerMutex.Lock()
-	var listeners = make(map[string]Listener)
-	for k, v := range m.listeners {
-		listeners[k] = v
+	var topics []string
+	for topic := range m.listeners {
+		topics = append(topics, topic)
 	}
 	m.listenerMutex.Unlock()
 
-	for topic, listener := range listeners {
-		delete(m.subscribedTopic, topic)
-		m.Subscribe(topic, listener)
+	var resubscribed []string
+	for _, topic := range topics {
+		ctx, cancel := context.WithTimeout(context.Background(), m.ReceiveTimeout)
+		err := m.sendSubscribe(ctx, topic)
+		cancel()
+		if err != nil {
+			debug.Println("resubscribe failed", topic, err)
+			continue
+		}
+		resubscribed = append(resubscribed, topic)
 	}
-	return nil
+	return resubscribed
 }
 
 // sendMessage 发送消息
 func (m *Market) sendMessage(data interface{}) error {
+	return m.sendMessageTo(m.conn.Current(), data)
+}
+
+// sendMessageTo 通过指定连接发送消息
+func (m *Market) sendMessageTo(ws *SafeWebSocket, data interface{}) error {
 	b, err := json.Marshal(data)
 	if err != nil {
-		return nil
+		return err
 	}
 	debug.Println("sendMessage", string(b))
-	m.ws.Send(b)
+	ws.Send(b)
 	return nil
 }
 
+// call 发送消息并等待结果，ctx取消或超时、连接断开时停止等待
+func (m *Market) call(ctx context.Context, cbs map[string]jsonChan, id string, data interface{}) (*simplejson.Json, error) {
+	result := m.addResultCb(cbs, id)
+	ws := m.conn.Current()
+	if err := m.sendMessageTo(ws, data); err != nil {
+		m.removeResultCb(cbs, id)
+		return nil, err
+	}
+
+	select {
+	case json := <-result:
+		return json, nil
+	case <-ctx.Done():
+		m.removeResultCb(cbs, id)
+		return nil, ctx.Err()
+	case <-ws.Done():
+		m.removeResultCb(cbs, id)
+		return nil, ConnectionClosedError
+	}
+}
+
 // handleMessageLoop 处理消息循环
-func (m *Market) handleMessageLoop() {
-	m.ws.Listen(func(buf []byte) {
+func (m *Market) handleMessageLoop(ws *SafeWebSocket) {
+	ws.Listen(func(buf []byte) {
 		msg, err := unGzipData(buf)
 		debug.Println("readMessage", string(msg))
 		if err != nil {
 			debug.Println(err)
 			return
 		}
-		json, err := simplejson.NewJson(msg)
-		if err != nil {
-			debug.Println(err)
-			return
-		}
+		m.handleMessage(msg)
+	})
+}
 
-		// 处理ping消息
-		if ping := json.Get("ping").MustInt64(); ping > 0 {
-			m.handlePing(pingData{Ping: ping})
-			return
-		}
+// handleMessage 处理解压后的消息
+func (m *Market) handleMessage(msg []byte) {
+	json, err := simplejson.NewJson(msg)
+	if err != nil {
+		debug.Println(err)
+		return
+	}
 
-		// 处理pong消息
-		if pong := json.Get("pong").MustInt64(); pong > 0 {
-			m.lastPing = pong
-			return
-		}
+	// 处理ping消息
+	if ping := json.Get("ping").MustInt64(); ping > 0 {
+		m.handlePing(pingData{Ping: ping})
+		return
+	}
 
-		// 处理订阅消息
-		if ch := json.Get("ch").MustString(); ch != "" {
-			m.listenerMutex.Lock()
-			listener, ok := m.listeners[ch]
-			m.listenerMutex.Unlock()
-			if ok {
-				debug.Println("handleSubscribe", json)
-				listener(ch, json)
-			}
-			return
-		}
+	// 处理pong消息
+	if pong := json.Get("pong").MustInt64(); pong > 0 {
+		m.conn.Touch()
+		return
+	}
 
-		// 处理订阅成功通知
-		if subbed := json.Get("subbed").MustString(); subbed != "" {
-			c, ok := m.subscribeResultCb[subbed]
-			if ok {
-				c <- json
-			}
-			return
+	// 处理订阅消息，依次调用该主题的所有监听器
+	if ch := json.Get("ch").MustString(); ch != "" {
+		m.listenerMutex.Lock()
+		handles := m.listeners[ch]
+		m.listenerMutex.Unlock()
+		if len(handles) > 0 {
+			debug.Println("handleSubscribe", json)
 		}
-
-		// 请求行情结果
-		if rep, id := json.Get("rep").MustString(), json.Get("id").MustString(); rep != "" && id != "" {
-			c, ok := m.requestResultCb[id]
-			if ok {
-				c <- json
-			}
-			return
+		for _, h := range handles {
+			h.listener(ch, json)
 		}
+		return
+	}
 
-		// 处理错误消息
-		if status := json.Get("status").MustString(); status == "error" {
-			// 判断是否为订阅失败
-			id := json.Get("id").MustString()
-			c, ok := m.subscribeResultCb[id]
-			if ok {
-				c <- json
-			}
-			return
-		}
-	})
-}
+	// 处理订阅成功通知
+	if subbed := json.Get("subbed").MustString(); subbed != "" {
+		m.resolveResultCb(m.subscribeResultCb, subbed, json)
+		return
+	}
 
-// keepAlive 保持活跃
-func (m *Market) keepAlive() {
-	m.ws.KeepAlive(m.HeartbeatInterval, func() {
-		var t = getUinxMillisecond()
-		m.sendMessage(pingData{Ping: t})
-
-		// 检查上次ping时间，如果超过20秒无响应，重新连接
-		tr := time.Duration(math.Abs(float64(t - m.lastPing)))
-		if tr >= m.HeartbeatInterval*2 {
-			debug.Println("no ping max delay", tr, m.HeartbeatInterval*2, t, m.lastPing)
-			if m.autoReconnect {
-				err := m.reconnect()
-				if err != nil {
-					debug.Println(err)
-				}
-			}
+	// 处理取消订阅成功通知
+	if unsubbed := json.Get("unsubbed").MustString(); unsubbed != "" {
+		m.resolveResultCb(m.unsubscribeResultCb, json.Get("id").MustString(), json)
+		return
+	}
+
+	// 请求行情结果
+	if rep, id := json.Get("rep").MustString(), json.Get("id").MustString(); rep != "" && id != "" {
+		m.resolveResultCb(m.requestResultCb, id, json)
+		return
+	}
+
+	// 处理错误消息
+	if status := json.Get("status").MustString(); status == "error" {
+		// 判断是否为订阅或取消订阅失败
+		id := json.Get("id").MustString()
+		if !m.resolveResultCb(m.subscribeResultCb, id, json) && !m.resolveResultCb(m.unsubscribeResultCb, id, json) {
+			m.resolveResultCb(m.requestResultCb, id, json)
 		}
-	})
+		return
+	}
 }
 
 // handlePing 处理Ping
 func (m *Market) handlePing(ping pingData) (err error) {
 	debug.Println("handlePing", ping)
-	m.lastPing = ping.Ping
+	m.conn.Touch()
 	var pong = pongData{Pong: ping.Ping}
 	err = m.sendMessage(pong)
 	if err != nil {
@@ -222,100 +283,272 @@ func (m *Market) handlePing(ping pingData) (err error) {
 	return nil
 }
 
-// Subscribe 订阅
+// resolveResultCb 将结果发送给等待中的调用者，返回是否有调用者在等待
+func (m *Market) resolveResultCb(cbs map[string]jsonChan, id string, json *simplejson.Json) bool {
+	m.resultCbMutex.Lock()
+	c, ok := cbs[id]
+	delete(cbs, id)
+	m.resultCbMutex.Unlock()
+	if ok {
+		// 通道有缓冲，调用者已放弃等待时也不会阻塞
+		c <- json
+	}
+	return ok
+}
+
+// addResultCb 注册等待结果的通道
+func (m *Market) addResultCb(cbs map[string]jsonChan, id string) jsonChan {
+	c := make(jsonChan, 1)
+	m.resultCbMutex.Lock()
+	cbs[id] = c
+	m.resultCbMutex.Unlock()
+	return c
+}
+
+// removeResultCb 取消等待结果
+func (m *Market) removeResultCb(cbs map[string]jsonChan, id string) {
+	m.resultCbMutex.Lock()
+	delete(cbs, id)
+	m.resultCbMutex.Unlock()
+}
+
+// Subscribe 订阅，同一主题可以添加多个监听器，最多等待ReceiveTimeout
 func (m *Market) Subscribe(topic string, listener Listener) error {
+	_, err := m.AddListener(topic, listener)
+	return err
+}
+
+// SubscribeWithContext 订阅，ctx取消或超时时停止等待订阅结果并移除监听器
+func (m *Market) SubscribeWithContext(ctx context.Context, topic string, listener Listener) error {
+	_, err := m.AddListenerWithContext(ctx, topic, listener)
+	return err
+}
+
+// AddListener 订阅并返回监听器句柄，可以通过句柄单独移除此监听器，最多等待ReceiveTimeout
+func (m *Market) AddListener(topic string, listener Listener) (*ListenerHandle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.ReceiveTimeout)
+	defer cancel()
+	return m.AddListenerWithContext(ctx, topic, listener)
+}
+
+// AddListenerWithContext 订阅并返回监听器句柄
+// 同一主题只在添加第一个监听器时发送订阅指令，之后添加的监听器等待同一个订阅结果，订阅失败时所有等待中的监听器都被移除并返回错误
+// ctx取消或超时时停止等待订阅结果并移除监听器，第一个监听器放弃等待时视为订阅失败
+func (m *Market) AddListenerWithContext(ctx context.Context, topic string, listener Listener) (*ListenerHandle, error) {
 	debug.Println("subscribe", topic)
+	h := &ListenerHandle{market: m, topic: topic, listener: listener}
 
-	var isNew = false
+	m.listenerMutex.Lock()
+	m.listeners[topic] = append(m.listeners[topic], h)
+	result, subscribed := m.subscribedTopic[topic]
+	if !subscribed {
+		result = &subscribeResult{done: make(chan struct{})}
+		m.subscribedTopic[topic] = result
+	}
+	select {
+	case <-result.done:
+	default:
+		result.handles = append(result.handles, h)
+	}
+	m.listenerMutex.Unlock()
 
-	// 如果未曾发送过订阅指令，则发送，并等待订阅操作结果，否则直接返回
-	if _, ok := m.subscribedTopic[topic]; !ok {
-		m.subscribeResultCb[topic] = make(jsonChan)
-		m.sendMessage(subData{ID: topic, Sub: topic})
-		isNew = true
-	} else {
-		debug.Println("send subscribe before, reset listener only")
+	// 已经发送过订阅指令时等待其结果
+	if subscribed {
+		debug.Println("send subscribe before, wait for result")
+		select {
+		case <-result.done:
+			if result.err != nil {
+				return nil, result.err
+			}
+			return h, nil
+		case <-ctx.Done():
+			m.listenerMutex.Lock()
+			m.removeHandleLocked(h)
+			m.listenerMutex.Unlock()
+			return nil, ctx.Err()
+		}
 	}
 
+	err := m.sendSubscribe(ctx, topic)
 	m.listenerMutex.Lock()
-	m.listeners[topic] = listener
+	if err != nil {
+		for _, v := range result.handles {
+			m.removeHandleLocked(v)
+		}
+		// 等待期间可能已经取消订阅并重新订阅
+		if m.subscribedTopic[topic] == result {
+			delete(m.subscribedTopic, topic)
+		}
+	}
+	result.err = err
+	result.handles = nil
+	close(result.done)
 	m.listenerMutex.Unlock()
-	m.subscribedTopic[topic] = true
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// sendSubscribe 发送订阅指令并等待订阅结果
+func (m *Market) sendSubscribe(ctx context.Context, topic string) error {
+	json, err := m.call(ctx, m.subscribeResultCb, topic, subData{ID: topic, Sub: topic})
+	if err != nil {
+		return err
+	}
+	// 判断订阅结果，如果出错则返回出错信息
+	if _, err := json.Get("err-msg").String(); err == nil {
+		return newAPIError(topic, json)
+	}
+	return nil
+}
 
-	if isNew {
-		var json = <-m.subscribeResultCb[topic]
-		// 判断订阅结果，如果出错则返回出错信息
-		if msg, err := json.Get("err-msg").String(); err == nil {
-			return fmt.Errorf(msg)
+// removeHandleLocked 从监听器列表中移除h，返回该主题是否已经没有监听器，调用前需要持有listenerMutex
+func (m *Market) removeHandleLocked(h *ListenerHandle) bool {
+	handles := m.listeners[h.topic]
+	for i, v := range handles {
+		if v == h {
+			// 复制一份，避免影响正在遍历旧列表的消息处理循环
+			handles = append(handles[:i:i], handles[i+1:]...)
+			break
 		}
 	}
+	if len(handles) == 0 {
+		delete(m.listeners, h.topic)
+		return true
+	}
+	m.listeners[h.topic] = handles
+	return false
+}
+
+// removeListener 移除监听器，主题没有监听器时发送取消订阅指令并等待结果
+func (m *Market) removeListener(ctx context.Context, h *ListenerHandle) error {
+	m.listenerMutex.Lock()
+	last := m.removeHandleLocked(h)
+	_, subscribed := m.subscribedTopic[h.topic]
+	if last && subscribed {
+		delete(m.subscribedTopic, h.topic)
+	}
+	m.listenerMutex.Unlock()
+
+	if !last || !subscribed {
+		return nil
+	}
+	return m.sendUnsubscribe(ctx, h.topic)
+}
+
+// sendUnsubscribe 发送取消订阅指令并等待结果
+func (m *Market) sendUnsubscribe(ctx context.Context, topic string) error {
+	var id = getRandomString(10)
+	json, err := m.call(ctx, m.unsubscribeResultCb, id, unsubData{ID: id, Unsub: topic})
+	if err != nil {
+		return err
+	}
+	if _, err := json.Get("err-msg").String(); err == nil {
+		return newAPIError(topic, json)
+	}
 	return nil
 }
 
-// Unsubscribe 取消订阅
-func (m *Market) Unsubscribe(topic string) {
+// Unsubscribe 取消订阅，移除该主题的所有监听器并关闭对应的通道订阅，最多等待ReceiveTimeout
+func (m *Market) Unsubscribe(topic string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.ReceiveTimeout)
+	defer cancel()
+	return m.UnsubscribeWithContext(ctx, topic)
+}
+
+// UnsubscribeWithContext 取消订阅，移除该主题的所有监听器并关闭对应的通道订阅
+// 不能在监听器中调用，否则会因为无法处理取消订阅结果而一直等待到ctx超时
+func (m *Market) UnsubscribeWithContext(ctx context.Context, topic string) error {
 	debug.Println("unSubscribe", topic)
 
 	m.listenerMutex.Lock()
-	// 火币网没有提供取消订阅的接口，只能删除监听器
 	delete(m.listeners, topic)
+	var streams []*Subscription
+	for s := range m.streams {
+		if s.Topic() == topic {
+			streams = append(streams, s)
+			delete(m.streams, s)
+		}
+	}
+	_, subscribed := m.subscribedTopic[topic]
+	delete(m.subscribedTopic, topic)
 	m.listenerMutex.Unlock()
+
+	for _, s := range streams {
+		s.close()
+	}
+
+	if !subscribed {
+		return nil
+	}
+	return m.sendUnsubscribe(ctx, topic)
 }
 
-// Request 请求行情信息
+// Request 请求行情信息，最多等待ReceiveTimeout，超时返回context.DeadlineExceeded
 func (m *Market) Request(req string) (*simplejson.Json, error) {
-	var id = getRandomString(10)
-	m.requestResultCb[id] = make(jsonChan)
+	ctx, cancel := context.WithTimeout(context.Background(), m.ReceiveTimeout)
+	defer cancel()
+	return m.RequestWithContext(ctx, req)
+}
 
-	if err := m.sendMessage(reqData{Req: req, ID: id}); err != nil {
+// RequestWithContext 请求行情信息，ctx取消或超时时停止等待并返回ctx.Err()，连接断开时返回ConnectionClosedError
+func (m *Market) RequestWithContext(ctx context.Context, req string) (*simplejson.Json, error) {
+	return m.RequestWithParams(ctx, req, nil)
+}
+
+// RequestWithParams 请求行情信息，params为附加的请求参数，例如K线的from和to
+func (m *Market) RequestWithParams(ctx context.Context, req string, params map[string]interface{}) (*simplejson.Json, error) {
+	var id = getRandomString(10)
+	var data interface{} = reqData{Req: req, ID: id}
+	if len(params) > 0 {
+		msg := make(map[string]interface{}, len(params)+2)
+		for k, v := range params {
+			msg[k] = v
+		}
+		msg["req"] = req
+		msg["id"] = id
+		data = msg
+	}
+	json, err := m.call(ctx, m.requestResultCb, id, data)
+	if err != nil {
 		return nil, err
 	}
-	var json = <-m.requestResultCb[id]
-
-	delete(m.requestResultCb, id)
 
 	// 判断是否出错
 	if msg := json.Get("err-msg").MustString(); msg != "" {
-		return json, fmt.Errorf(msg)
+		return json, newAPIError(req, json)
 	}
 	return json, nil
 }
 
-// Loop 进入循环
+// Loop 进入循环，连接断开时自动重连，直到执行Close()或放弃重连才退出
 func (m *Market) Loop() {
-	debug.Println("startLoop")
-	for {
-		err := m.ws.Loop()
-		if err != nil {
-			debug.Println(err)
-			if err == SafeWebSocketDestroyError {
-				break
-			} else if m.autoReconnect {
-				m.reconnect()
-			} else {
-				break
-			}
+	m.conn.Loop()
+}
+
+// LoopWithContext 进入循环，ctx取消或超时时关闭连接并退出
+func (m *Market) LoopWithContext(ctx context.Context) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.Close()
+		case <-done:
 		}
-	}
-	debug.Println("endLoop")
+	}()
+	m.Loop()
 }
 
-// ReConnect 重新连接
+// ReConnect 重新连接，按重连策略重试，之前执行过Close()或放弃重连时恢复自动重连
 func (m *Market) ReConnect() (err error) {
-	debug.Println("reconnect")
-	m.autoReconnect = true
-	if err = m.ws.Destroy(); err != nil {
-		return err
-	}
-	return m.reconnect()
+	return m.conn.ReConnect()
 }
 
-// Close 关闭连接
+// Close 关闭连接，并关闭所有通道订阅
 func (m *Market) Close() error {
-	debug.Println("close")
-	m.autoReconnect = false
-	if err := m.ws.Destroy(); err != nil {
-		return err
-	}
-	return nil
+	err := m.conn.Close()
+	m.closeStreams()
+	return err
 }
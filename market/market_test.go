@@ -1,76 +1,167 @@
 package market
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
-	"strings"
-
 	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/market/markettest"
 	"github.com/stretchr/testify/assert"
 )
 
+const testKlineTick = `{"id":1516870800,"amount":280.4836,"count":9,"open":14.29,"close":14.29,"low":14.29,"high":14.3,"vol":4010.253191}`
+
+// waitFor 等待cond成立，超时则测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	if !markettest.WaitFor(5*time.Second, cond) {
+		t.Fatal("timeout")
+	}
+}
+
 func TestNewMarket(t *testing.T) {
-	m, err := NewMarket()
+	server := markettest.NewServer()
+	defer server.Close()
+	server.OnSubscribe("market.eosusdt.kline.1min", testKlineTick)
+	server.SetResponse("market.eosusdt.detail", `{"id":1516870800,"close":14.29}`)
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
 	assert.NoError(t, err)
 
 	// 订阅
+	received := make(chan string, 10)
 	err = m.Subscribe("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {
 		fmt.Println(topic, json)
+		received <- topic
 	})
 	assert.NoError(t, err)
 	err = m.Subscribe("market.eosusdt.trade.detail", func(topic string, json *simplejson.Json) {
 		fmt.Println(topic, json)
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, "market.eosusdt.kline.1min", <-received)
 
 	// 请求
 	rep, err := m.Request("market.eosusdt.detail")
 	assert.NoError(t, err)
-	fmt.Println(rep)
+	assert.Equal(t, 14.29, rep.Get("data").Get("close").MustFloat64())
+	_, err = m.Request("market.abcusdt.detail")
+	assert.EqualError(t, err, "invalid topic market.abcusdt.detail")
 
 	// 阻塞事件循环
-	fmt.Println(m)
 	go func() {
-		time.Sleep(time.Second * 10)
+		time.Sleep(100 * time.Millisecond)
 		m.Close()
 	}()
 	m.Loop()
+	waitFor(t, func() bool { return server.Conns() == 0 })
 
-	fmt.Println(strings.Repeat("-------------------\n", 10))
-
-	// 重新连接
-	m.ReConnect()
-	go func() {
-		time.Sleep(time.Second * 12)
-		m.Close()
-	}()
-	go func() {
-		for {
-			time.Sleep(time.Second * 2)
-			rep, err := m.Request("market.eosusdt.detail")
-			fmt.Println(err, rep)
-		}
-	}()
-	m.Loop()
+	// 重新连接，并重新订阅所有主题
+	assert.NoError(t, m.ReConnect())
+	assert.Equal(t, "market.eosusdt.kline.1min", <-received)
+	assert.Equal(t, 2, server.Subs("market.eosusdt.kline.1min"))
+	assert.Equal(t, 2, server.Subs("market.eosusdt.trade.detail"))
+	rep, err = m.Request("market.eosusdt.detail")
+	assert.NoError(t, err)
 
-	fmt.Println(m)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	m.LoopWithContext(ctx)
+	waitFor(t, func() bool { return server.Conns() == 0 })
 }
 
 func TestMarketAlive(t *testing.T) {
-	m, err := NewMarket()
+	server := markettest.NewServer()
+	defer server.Close()
+	server.OnSubscribe("market.eosusdt.kline.1min", testKlineTick)
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
 	assert.NoError(t, err)
+	received := make(chan int, 10)
 	err = m.Subscribe("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {
-		fmt.Println(topic, json)
+		received <- 1
 	})
+	assert.NoError(t, err)
 	err = m.Subscribe("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {
-		fmt.Println(topic, json)
+		received <- 2
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, 1, <-received)
+
+	done := make(chan struct{})
 	go func() {
-		time.Sleep(time.Minute * 2)
-		m.Close()
+		m.Loop()
+		close(done)
 	}()
-	m.Loop()
+
+	// 服务器断开连接后自动重连并重新订阅，两个监听器都会收到消息
+	server.CloseConnections()
+	assert.Equal(t, 1, <-received)
+	assert.Equal(t, 2, <-received)
+	assert.Equal(t, 2, server.Subs("market.eosusdt.kline.1min"))
+	assert.Equal(t, 1, server.Conns())
+
+	// 并发操作
+	for i := 0; i < 10; i++ {
+		go m.Request("market.eosusdt.kline.1min")
+		go m.AddListener("market.eosusdt.trade.detail", func(topic string, json *simplejson.Json) {})
+	}
+
+	m.Close()
+	<-done
+	waitFor(t, func() bool { return server.Conns() == 0 })
+}
+
+func TestMarket_SubscribeError(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetError("market.abcusdt.kline.1min", "bad-request", "invalid topic market.abcusdt.kline.1min")
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	err = m.Subscribe("market.abcusdt.kline.1min", func(topic string, json *simplejson.Json) {})
+	assert.EqualError(t, err, "invalid topic market.abcusdt.kline.1min")
+	assert.Equal(t, 0, server.Subscribers("market.abcusdt.kline.1min"))
+
+	// 订阅结果返回前ctx超时
+	server.SetAckDelay(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = m.SubscribeWithContext(ctx, "market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// 等待结果时连接断开
+	result := make(chan error)
+	go func() {
+		_, err := m.Request("market.eosusdt.detail")
+		result <- err
+	}()
+	waitFor(t, func() bool { return server.Requests("market.eosusdt.detail") == 1 })
+	server.CloseConnections()
+	assert.Equal(t, ConnectionClosedError, <-result)
+}
+
+func TestMarket_ReceiveTimeout(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL), WithReceiveTimeout(50*time.Millisecond))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// 服务器正常回复心跳但不回复请求和订阅结果
+	server.SetAckDelay(time.Second)
+	start := time.Now()
+	_, err = m.Request("market.eosusdt.detail")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.True(t, time.Since(start) < time.Second)
+
+	err = m.Subscribe("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	_, err = m.AddListener("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {})
+	assert.Equal(t, context.DeadlineExceeded, err)
 }
@@ -0,0 +1,467 @@
+// Package markettest 提供模拟的火币行情WebSocket服务器，用于在没有网络的环境中测试行情订阅逻辑
+package markettest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Server 模拟的火币行情WebSocket服务器
+// 支持gzip压缩消息、服务端ping和客户端pong、sub/subbed、unsub/unsubbed、req/rep及错误状态
+type Server struct {
+	// 服务器的WebSocket入口地址，例如ws://127.0.0.1:12345/ws
+	URL string
+
+	server *httptest.Server
+
+	mutex        sync.Mutex
+	conns        map[*conn]struct{}
+	pingInterval time.Duration
+	ackDelay     time.Duration
+	dropPings    bool
+	initial      map[string][]string
+	responses    map[string]string
+	handlers     map[string]RequestHandler
+	errors       map[string]apiError
+	subs         map[string]int
+	unsubs       map[string]int
+	requests     map[string]int
+	pongs        int
+	connected    int
+	header       http.Header
+}
+
+type apiError struct {
+	code    string
+	message string
+}
+
+// Request 客户端发送的req请求
+type Request struct {
+	Topic string
+	ID    string
+	// 除req和id以外的请求参数，例如K线的from和to
+	Params map[string]json.RawMessage
+}
+
+// Error 请求处理函数返回的错误，作为status为error的消息返回给客户端
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// RequestHandler 请求处理函数，返回data字段的JSON，返回*Error以外的错误时错误码为bad-request
+type RequestHandler = func(r *Request) (string, error)
+
+// conn 一个客户端连接
+type conn struct {
+	ws *websocket.Conn
+	// 保护写入操作和topics
+	mutex     sync.Mutex
+	topics    map[string]bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer 启动模拟服务器，默认每5秒发送一次ping
+func NewServer() *Server {
+	s := &Server{
+		conns:        make(map[*conn]struct{}),
+		pingInterval: 5 * time.Second,
+		initial:      make(map[string][]string),
+		responses:    make(map[string]string),
+		handlers:     make(map[string]RequestHandler),
+		errors:       make(map[string]apiError),
+		subs:         make(map[string]int),
+		unsubs:       make(map[string]int),
+		requests:     make(map[string]int),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = "ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws"
+	return s
+}
+
+// Close 断开所有连接并关闭服务器
+func (s *Server) Close() {
+	s.CloseConnections()
+	s.server.Close()
+}
+
+// SetPingInterval 设置服务端发送ping的间隔，0表示不发送，对之后建立的连接生效
+func (s *Server) SetPingInterval(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pingInterval = d
+}
+
+// SetAckDelay 设置回复subbed、unsubbed和rep之前的延迟，用于模拟响应缓慢
+func (s *Server) SetAckDelay(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ackDelay = d
+}
+
+// DropPings 设置是否停止发送ping并忽略客户端的ping，用于模拟心跳超时
+func (s *Server) DropPings(drop bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropPings = drop
+}
+
+// OnSubscribe 设置订阅topic成功后立即推送的消息，tick为消息中tick字段的JSON
+func (s *Server) OnSubscribe(topic string, ticks ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.initial[topic] = ticks
+}
+
+// SetResponse 设置请求topic时返回的data字段的JSON
+func (s *Server) SetResponse(topic, data string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[topic] = data
+}
+
+// HandleRequest 设置处理topic请求的函数，优先于SetResponse，在读取消息的goroutine中调用
+func (s *Server) HandleRequest(topic string, h RequestHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[topic] = h
+}
+
+// SetError 设置订阅或请求topic时返回的错误
+func (s *Server) SetError(topic, code, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errors[topic] = apiError{code, message}
+}
+
+// Publish 向所有订阅了topic的连接推送消息，tick为消息中tick字段的JSON，返回推送的连接数量
+func (s *Server) Publish(topic, tick string) int {
+	msg := fmt.Sprintf(`{"ch":%q,"ts":%d,"tick":%s}`, topic, timestamp(), tick)
+	n := 0
+	for _, c := range s.connList() {
+		if c.subscribed(topic) && c.send(msg) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// CloseConnections 立即断开所有连接，不发送关闭帧，用于模拟网络异常
+func (s *Server) CloseConnections() {
+	for _, c := range s.connList() {
+		c.close()
+	}
+}
+
+// Conns 当前的连接数量
+func (s *Server) Conns() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.conns)
+}
+
+// Connected 累计建立过的连接数量
+func (s *Server) Connected() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.connected
+}
+
+// Header 最近一次建立连接时客户端发送的请求头
+func (s *Server) Header() http.Header {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.header
+}
+
+// Subscribers 当前订阅了topic的连接数量
+func (s *Server) Subscribers(topic string) int {
+	n := 0
+	for _, c := range s.connList() {
+		if c.subscribed(topic) {
+			n++
+		}
+	}
+	return n
+}
+
+// Subs 累计收到的topic订阅指令数量
+func (s *Server) Subs(topic string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.subs[topic]
+}
+
+// Unsubs 累计收到的topic取消订阅指令数量
+func (s *Server) Unsubs(topic string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.unsubs[topic]
+}
+
+// Requests 累计收到的topic请求数量
+func (s *Server) Requests(topic string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[topic]
+}
+
+// Pongs 累计收到的客户端pong数量
+func (s *Server) Pongs() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pongs
+}
+
+// WaitFor 每10毫秒检查一次cond，直到cond成立或超时，返回cond是否成立
+func WaitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func (s *Server) connList() []*conn {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		list = append(list, c)
+	}
+	return list
+}
+
+// handle 处理一个WebSocket连接
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws, topics: make(map[string]bool), done: make(chan struct{})}
+
+	s.mutex.Lock()
+	s.conns[c] = struct{}{}
+	s.connected++
+	s.header = r.Header
+	pingInterval := s.pingInterval
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, c)
+		s.mutex.Unlock()
+		c.close()
+	}()
+
+	if pingInterval > 0 {
+		go s.pingLoop(c, pingInterval)
+	}
+
+	for {
+		_, b, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Ping  int64  `json:"ping"`
+			Pong  int64  `json:"pong"`
+			Sub   string `json:"sub"`
+			Unsub string `json:"unsub"`
+			Req   string `json:"req"`
+			ID    string `json:"id"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil {
+			continue
+		}
+		switch {
+		case msg.Ping > 0:
+			s.mutex.Lock()
+			drop := s.dropPings
+			s.mutex.Unlock()
+			if !drop {
+				c.send(fmt.Sprintf(`{"pong":%d}`, msg.Ping))
+			}
+		case msg.Pong > 0:
+			s.mutex.Lock()
+			s.pongs++
+			s.mutex.Unlock()
+		case msg.Sub != "":
+			s.handleSub(c, msg.Sub, msg.ID)
+		case msg.Unsub != "":
+			s.handleUnsub(c, msg.Unsub, msg.ID)
+		case msg.Req != "":
+			var params map[string]json.RawMessage
+			json.Unmarshal(b, &params)
+			delete(params, "req")
+			delete(params, "id")
+			s.handleReq(c, &Request{Topic: msg.Req, ID: msg.ID, Params: params})
+		}
+	}
+}
+
+// pingLoop 定期向客户端发送ping
+func (s *Server) pingLoop(c *conn, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mutex.Lock()
+			drop := s.dropPings
+			s.mutex.Unlock()
+			if !drop {
+				c.send(fmt.Sprintf(`{"ping":%d}`, timestamp()))
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// ack 按SetAckDelay设置的延迟回复消息
+func (s *Server) ack(c *conn, f func()) {
+	s.mutex.Lock()
+	delay := s.ackDelay
+	s.mutex.Unlock()
+	if delay <= 0 {
+		f()
+		return
+	}
+	go func() {
+		select {
+		case <-time.After(delay):
+			f()
+		case <-c.done:
+		}
+	}()
+}
+
+func (s *Server) handleSub(c *conn, topic, id string) {
+	s.mutex.Lock()
+	s.subs[topic]++
+	e, failed := s.errors[topic]
+	ticks := s.initial[topic]
+	s.mutex.Unlock()
+
+	s.ack(c, func() {
+		if failed {
+			c.sendError(id, e)
+			return
+		}
+		c.setSubscribed(topic, true)
+		c.send(fmt.Sprintf(`{"id":%q,"status":"ok","subbed":%q,"ts":%d}`, id, topic, timestamp()))
+		for _, tick := range ticks {
+			c.send(fmt.Sprintf(`{"ch":%q,"ts":%d,"tick":%s}`, topic, timestamp(), tick))
+		}
+	})
+}
+
+func (s *Server) handleUnsub(c *conn, topic, id string) {
+	s.mutex.Lock()
+	s.unsubs[topic]++
+	s.mutex.Unlock()
+
+	s.ack(c, func() {
+		if !c.subscribed(topic) {
+			c.sendError(id, apiError{"bad-request", "unsub with not subbed topic " + topic})
+			return
+		}
+		c.setSubscribed(topic, false)
+		c.send(fmt.Sprintf(`{"id":%q,"status":"ok","unsubbed":%q,"ts":%d}`, id, topic, timestamp()))
+	})
+}
+
+func (s *Server) handleReq(c *conn, r *Request) {
+	topic, id := r.Topic, r.ID
+	s.mutex.Lock()
+	s.requests[topic]++
+	e, failed := s.errors[topic]
+	data, ok := s.responses[topic]
+	h := s.handlers[topic]
+	s.mutex.Unlock()
+	if !failed && h != nil {
+		var err error
+		if data, err = h(r); err != nil {
+			if he, isError := err.(*Error); isError {
+				failed, e = true, apiError{he.Code, he.Message}
+			} else {
+				failed, e = true, apiError{"bad-request", err.Error()}
+			}
+		}
+		ok = true
+	}
+	if !failed && !ok {
+		failed, e = true, apiError{"bad-request", "invalid topic " + topic}
+	}
+
+	s.ack(c, func() {
+		if failed {
+			c.sendError(id, e)
+			return
+		}
+		c.send(fmt.Sprintf(`{"id":%q,"status":"ok","rep":%q,"ts":%d,"data":%s}`, id, topic, timestamp(), data))
+	})
+}
+
+// send 发送gzip压缩后的消息
+func (c *conn) send(msg string) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(msg))
+	zw.Close()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ws.WriteMessage(websocket.BinaryMessage, buf.Bytes())
+}
+
+func (c *conn) sendError(id string, e apiError) error {
+	return c.send(fmt.Sprintf(`{"id":%q,"status":"error","err-code":%q,"err-msg":%q,"ts":%d}`, id, e.code, e.message, timestamp()))
+}
+
+func (c *conn) subscribed(topic string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.topics[topic]
+}
+
+func (c *conn) setSubscribed(topic string, v bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if v {
+		c.topics[topic] = true
+	} else {
+		delete(c.topics, topic)
+	}
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.ws.Close()
+	})
+}
+
+func timestamp() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,52 @@
+package markettest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/market"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetPingInterval(20 * time.Millisecond)
+	server.SetResponse("market.eosusdt.detail", `{"close":14.29}`)
+	server.OnSubscribe("market.eosusdt.kline.1min", `{"id":1,"close":14.29}`)
+
+	m, err := market.NewMarketWithOptions(market.WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+	assert.Equal(t, 1, server.Conns())
+
+	// 客户端回复服务端的ping
+	assert.True(t, markettest.WaitFor(time.Second, func() bool { return server.Pongs() >= 2 }))
+
+	received := make(chan float64, 10)
+	err = m.Subscribe("market.eosusdt.kline.1min", func(topic string, json *simplejson.Json) {
+		received <- json.Get("tick").Get("close").MustFloat64()
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 14.29, <-received)
+	assert.Equal(t, 1, server.Subscribers("market.eosusdt.kline.1min"))
+	assert.Equal(t, 1, server.Publish("market.eosusdt.kline.1min", `{"id":2,"close":14.3}`))
+	assert.Equal(t, 14.3, <-received)
+	assert.Equal(t, 0, server.Publish("market.eosusdt.trade.detail", `{}`))
+
+	rep, err := m.Request("market.eosusdt.detail")
+	assert.NoError(t, err)
+	assert.Equal(t, 14.29, rep.Get("data").Get("close").MustFloat64())
+	_, err = m.Request("market.abcusdt.detail")
+	assert.EqualError(t, err, "invalid topic market.abcusdt.detail")
+
+	assert.NoError(t, m.Unsubscribe("market.eosusdt.kline.1min"))
+	assert.Equal(t, 1, server.Unsubs("market.eosusdt.kline.1min"))
+	assert.Equal(t, 0, server.Subscribers("market.eosusdt.kline.1min"))
+
+	server.CloseConnections()
+	assert.True(t, markettest.WaitFor(time.Second, func() bool { return server.Conns() == 0 }))
+	assert.Equal(t, 1, server.Connected())
+}
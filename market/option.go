@@ -0,0 +1,151 @@
+package market
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leizongmin/huobiapi/client"
+)
+
+// ReconnectPolicy 断线重连策略，重连失败后按指数退避增加等待时间
+type ReconnectPolicy struct {
+	// 首次重连前的等待时间
+	Delay time.Duration
+	// 最大等待时间，为0时不限制
+	MaxDelay time.Duration
+	// 每次重连失败后等待时间的增长倍数，小于1时按1处理
+	Multiplier float64
+	// 随机抖动比例，取值0~1，例如0.2表示在等待时间上下浮动20%
+	Jitter float64
+	// 最大重连次数，为0时不限制
+	MaxAttempts int
+	// 从断线开始最长的重连时间，为0时不限制
+	MaxElapsedTime time.Duration
+}
+
+// DefaultReconnectPolicy 默认的断线重连策略，无限次重连
+var DefaultReconnectPolicy = ReconnectPolicy{
+	Delay:      time.Second,
+	MaxDelay:   30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Backoff 第attempt次重连前需要等待的时间，attempt从1开始，与client.RetryPolicy使用相同的退避算法
+func (p ReconnectPolicy) Backoff(attempt int) time.Duration {
+	return client.RetryPolicy{
+		InitialBackoff: p.Delay,
+		MaxBackoff:     p.MaxDelay,
+		Multiplier:     p.Multiplier,
+		Jitter:         p.Jitter,
+	}.Backoff(attempt)
+}
+
+// Hooks 连接生命周期回调，在建立连接或重连的goroutine中同步调用，不应长时间阻塞
+type Hooks struct {
+	// 建立连接后调用，包括首次连接和每次重连成功
+	OnConnected func()
+	// 连接断开后、开始重连前调用，err为断开的原因
+	OnDisconnected func(err error)
+	// 每次尝试重连前调用，attempt从1开始
+	OnReconnecting func(attempt int)
+	// 重连成功并重新订阅后调用，topics为重新订阅成功的主题
+	OnResubscribed func(topics []string)
+	// 超过最大重连次数或最长重连时间后调用，err为最后一次重连失败的原因，之后不再自动重连
+	OnGaveUp func(err error)
+}
+
+// Option 创建Market的选项
+type Option func(o *options)
+
+type options struct {
+	endpoint          string
+	dialer            *websocket.Dialer
+	header            http.Header
+	heartbeatInterval time.Duration
+	receiveTimeout    time.Duration
+	reconnect         ReconnectPolicy
+	hooks             Hooks
+	clock             client.Clock
+}
+
+// WithEndpoint 设置WebSocket入口地址，默认为创建时的Endpoint
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithDialer 设置建立连接使用的websocket.Dialer，可指定代理、TLS配置、握手超时和压缩等，默认为websocket.DefaultDialer
+func WithDialer(d *websocket.Dialer) Option {
+	return func(o *options) {
+		o.dialer = d
+	}
+}
+
+// WithHeader 设置建立连接时附加的请求头
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithHeartbeatInterval 设置主动发送心跳的时间间隔，默认5秒
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatInterval = d
+	}
+}
+
+// WithReceiveTimeout 设置等待订阅、取消订阅和请求结果的超时时间，默认10秒
+func WithReceiveTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.receiveTimeout = d
+	}
+}
+
+// WithReconnectPolicy 设置断线重连策略，默认为DefaultReconnectPolicy
+func WithReconnectPolicy(p ReconnectPolicy) Option {
+	return func(o *options) {
+		o.reconnect = p
+	}
+}
+
+// WithHooks 设置连接生命周期回调
+func WithHooks(h Hooks) Option {
+	return func(o *options) {
+		o.hooks = h
+	}
+}
+
+// WithClock 设置计算心跳时间戳使用的时钟，默认为本地时钟
+func WithClock(c client.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		endpoint:          Endpoint,
+		dialer:            websocket.DefaultDialer,
+		heartbeatInterval: 5 * time.Second,
+		receiveTimeout:    10 * time.Second,
+		reconnect:         DefaultReconnectPolicy,
+		clock:             client.LocalClock,
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	if o.dialer == nil {
+		o.dialer = websocket.DefaultDialer
+	}
+	if o.clock == nil {
+		o.clock = client.LocalClock
+	}
+	return o
+}
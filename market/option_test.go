@@ -0,0 +1,155 @@
+package market
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/gorilla/websocket"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMarketWithOptions(t *testing.T) {
+	noop := func(topic string, json *simplejson.Json) {}
+	server1 := markettest.NewServer()
+	defer server1.Close()
+	server2 := markettest.NewServer()
+	defer server2.Close()
+
+	// 两个实例分别连接不同的服务器，不修改全局的Endpoint
+	m1, err := NewMarketWithOptions(
+		WithEndpoint(server1.URL),
+		WithDialer(&websocket.Dialer{HandshakeTimeout: time.Second}),
+		WithHeader("X-Test", "m1"),
+		WithHeartbeatInterval(time.Second),
+		WithReceiveTimeout(2*time.Second),
+		WithReconnectPolicy(ReconnectPolicy{Delay: 10 * time.Millisecond}),
+	)
+	assert.NoError(t, err)
+	defer m1.Close()
+	m2, err := NewMarketWithOptions(WithEndpoint(server2.URL))
+	assert.NoError(t, err)
+	defer m2.Close()
+
+	assert.Equal(t, "m1", server1.Header().Get("X-Test"))
+	assert.Equal(t, "", server2.Header().Get("X-Test"))
+	assert.Equal(t, time.Second, m1.HeartbeatInterval)
+	assert.Equal(t, 2*time.Second, m1.ReceiveTimeout)
+	assert.Equal(t, 5*time.Second, m2.HeartbeatInterval)
+	assert.Equal(t, 10*time.Second, m2.ReceiveTimeout)
+
+	assert.NoError(t, m1.Subscribe("market.eosusdt.detail", noop))
+	assert.NoError(t, m2.Subscribe("market.btcusdt.detail", noop))
+	assert.Equal(t, 1, server1.Subscribers("market.eosusdt.detail"))
+	assert.Equal(t, 0, server1.Subscribers("market.btcusdt.detail"))
+	assert.Equal(t, 1, server2.Subscribers("market.btcusdt.detail"))
+
+	// 按重连策略的等待时间重连
+	go m1.Loop()
+	server1.CloseConnections()
+	assert.True(t, markettest.WaitFor(500*time.Millisecond, func() bool {
+		return server1.Connected() == 2 && server1.Subscribers("market.eosusdt.detail") == 1
+	}))
+	assert.Equal(t, 1, server2.Connected())
+
+	_, err = NewMarketWithOptions(WithEndpoint("ws://127.0.0.1:1/ws"))
+	assert.Error(t, err)
+}
+
+func TestReconnectPolicy_Backoff(t *testing.T) {
+	p := ReconnectPolicy{Delay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(0))
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.Backoff(4))
+	assert.Equal(t, time.Second, p.Backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(2)
+		assert.True(t, d >= 100*time.Millisecond && d <= 300*time.Millisecond, d)
+	}
+
+	// 倍数小于1时等待时间不变
+	p = ReconnectPolicy{Delay: 100 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(5))
+}
+
+func TestMarket_Hooks(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+
+	events := make(chan string, 100)
+	m, err := NewMarketWithOptions(
+		WithEndpoint(server.URL),
+		WithReconnectPolicy(ReconnectPolicy{Delay: 10 * time.Millisecond, Multiplier: 2, MaxAttempts: 3}),
+		WithHooks(Hooks{
+			OnConnected:    func() { events <- "connected" },
+			OnDisconnected: func(err error) { events <- fmt.Sprint("disconnected ", err != nil) },
+			OnReconnecting: func(attempt int) { events <- fmt.Sprint("reconnecting ", attempt) },
+			OnResubscribed: func(topics []string) { events <- fmt.Sprint("resubscribed ", topics) },
+			OnGaveUp:       func(err error) { events <- fmt.Sprint("gave up ", err != nil) },
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "connected", <-events)
+	klines, _, err := m.SubscribeKline("eosusdt", "1min")
+	assert.NoError(t, err)
+
+	loopDone := make(chan struct{})
+	go func() {
+		m.Loop()
+		close(loopDone)
+	}()
+
+	// 断线后重连并重新订阅
+	server.CloseConnections()
+	assert.Equal(t, "disconnected true", <-events)
+	assert.Equal(t, "reconnecting 1", <-events)
+	assert.Equal(t, "connected", <-events)
+	assert.Equal(t, "resubscribed [market.eosusdt.kline.1min]", <-events)
+	waitFor(t, func() bool { return server.Subscribers("market.eosusdt.kline.1min") == 1 })
+
+	// 服务器无法连接时，超过最大重连次数后放弃，并关闭通道订阅
+	server.Close()
+	assert.Equal(t, "disconnected true", <-events)
+	assert.Equal(t, "reconnecting 1", <-events)
+	assert.Equal(t, "reconnecting 2", <-events)
+	assert.Equal(t, "reconnecting 3", <-events)
+	assert.Equal(t, "gave up true", <-events)
+	<-loopDone
+	_, ok := <-klines
+	assert.False(t, ok)
+}
+
+func TestMarket_CloseDuringReconnect(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+
+	reconnecting := make(chan int, 10)
+	m, err := NewMarketWithOptions(
+		WithEndpoint(server.URL),
+		WithReconnectPolicy(ReconnectPolicy{Delay: time.Hour}),
+		WithHooks(Hooks{OnReconnecting: func(attempt int) { reconnecting <- attempt }}),
+	)
+	assert.NoError(t, err)
+
+	loopDone := make(chan struct{})
+	go func() {
+		m.Loop()
+		close(loopDone)
+	}()
+	server.CloseConnections()
+	assert.Equal(t, 1, <-reconnecting)
+
+	// Close()中断重连前的等待
+	m.Close()
+	select {
+	case <-loopDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	assert.Equal(t, 1, server.Connected())
+}
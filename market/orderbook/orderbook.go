@@ -0,0 +1,360 @@
+// Package orderbook 基于market.Market在本地维护L2订单簿
+// 订阅market.$symbol.mbp.$levels增量数据，通过req请求全量快照，按seqNum和prevSeqNum对齐，发现缺失时自动重新同步
+package orderbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/debug"
+	"github.com/leizongmin/huobiapi/market"
+)
+
+// ClosedError 订单簿已关闭
+var ClosedError = fmt.Errorf("order book closed")
+
+// GapError 增量数据的序号不连续
+var GapError = fmt.Errorf("order book sequence gap")
+
+// resyncDelay 重新同步失败后再次尝试前的等待时间
+const resyncDelay = time.Second
+
+// maxBuffer 同步期间最多缓存的增量数据条数，超过时丢弃最旧的数据
+const maxBuffer = 1000
+
+// maxInitialSyncs 首次同步时因快照早于增量数据而失败的最多尝试次数
+const maxInitialSyncs = 3
+
+// Level 一档价格及数量
+type Level struct {
+	Price  data_type.Decimal
+	Amount data_type.Decimal
+}
+
+// depth 各档价格及对应的序号，同步时在副本中构建，成功后再替换
+type depth struct {
+	// 最多保留的档数
+	levels int
+	bids   []Level
+	asks   []Level
+	// 当前订单簿对应的序号
+	seqNum uint
+	// 刚应用了快照，下一条增量数据的prevSeqNum可以小于等于seqNum
+	fromSnapshot bool
+}
+
+// Book 本地维护的L2订单簿，可以在多个goroutine中并发读取
+type Book struct {
+	market *market.Market
+	topic  string
+	handle *market.ListenerHandle
+	// 订单簿有变化时发送通知，缓冲区为1，多次变化会合并为一次通知
+	updated chan struct{}
+	done    chan struct{}
+
+	// depth及以下字段由mutex保护
+	mutex sync.RWMutex
+	depth
+	// 是否已与快照对齐，未对齐时缓存收到的增量数据
+	synced bool
+	// 是否有goroutine正在重新同步
+	syncing bool
+	buffer  []data_type.MBPTickDecimal
+	resyncs int
+	closed  bool
+}
+
+// New 订阅symbol的levels档增量数据并同步全量快照，levels可选5、20、150、400
+// 快照早于缓存的增量数据时与重新同步一样等待后重试，最多尝试maxInitialSyncs次，首次同步失败时取消订阅并返回错误
+func New(m *market.Market, symbol string, levels int) (*Book, error) {
+	b := &Book{
+		market:  m,
+		depth:   depth{levels: levels},
+		topic:   fmt.Sprintf("market.%s.mbp.%d", symbol, levels),
+		updated: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		syncing: true,
+	}
+	h, err := m.AddListener(b.topic, b.receive)
+	if err != nil {
+		return nil, err
+	}
+	b.handle = h
+	for attempt := 1; ; attempt++ {
+		err := b.sync()
+		if err == nil {
+			return b, nil
+		}
+		if err != GapError || attempt >= maxInitialSyncs {
+			b.Close()
+			return nil, err
+		}
+		debug.Println(b.topic, "initial sync failed", err)
+		time.Sleep(resyncDelay)
+	}
+}
+
+// Topic 订阅的增量数据主题
+func (b *Book) Topic() string {
+	return b.topic
+}
+
+// Close 取消订阅，之后不再更新，最多等待market的ReceiveTimeout
+func (b *Book) Close() {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	b.closed = true
+	b.synced = false
+	b.buffer = nil
+	close(b.done)
+	b.mutex.Unlock()
+	b.handle.Unsubscribe()
+}
+
+// Updated 订单簿有变化时收到通知，多次变化可能合并为一次
+func (b *Book) Updated() <-chan struct{} {
+	return b.updated
+}
+
+// Synced 是否已与快照对齐，重新同步期间返回false，此时的数据可能已经过期
+func (b *Book) Synced() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.synced
+}
+
+// SeqNum 当前订单簿对应的序号
+func (b *Book) SeqNum() uint {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.seqNum
+}
+
+// Resyncs 因序号不连续而重新同步的次数
+func (b *Book) Resyncs() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.resyncs
+}
+
+// BestBid 买一价，没有买单时返回false
+func (b *Book) BestBid() (Level, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if len(b.bids) == 0 {
+		return Level{}, false
+	}
+	return b.bids[0], true
+}
+
+// BestAsk 卖一价，没有卖单时返回false
+func (b *Book) BestAsk() (Level, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if len(b.asks) == 0 {
+		return Level{}, false
+	}
+	return b.asks[0], true
+}
+
+// Bids 价格从高到低的前n档买单，n小于等于0时返回全部
+func (b *Book) Bids(n int) []Level {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return top(b.bids, n)
+}
+
+// Asks 价格从低到高的前n档卖单，n小于等于0时返回全部
+func (b *Book) Asks(n int) []Level {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return top(b.asks, n)
+}
+
+func top(levels []Level, n int) []Level {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return append([]Level(nil), levels[:n]...)
+}
+
+// receive 处理增量数据，在消息处理循环中调用，不能等待请求结果
+func (b *Book) receive(topic string, json *simplejson.Json) {
+	raw, err := json.Encode()
+	if err != nil {
+		debug.Println(err)
+		return
+	}
+	v, err := data_type.DecodeMBPDecimal(raw)
+	if err != nil {
+		debug.Println("decode failed", topic, err)
+		return
+	}
+
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	if !b.synced {
+		if len(b.buffer) >= maxBuffer {
+			b.buffer = b.buffer[1:]
+		}
+		b.buffer = append(b.buffer, v.Tick)
+		b.mutex.Unlock()
+		return
+	}
+	if err := b.apply(v.Tick); err != nil {
+		debug.Println(b.topic, err, "resync")
+		b.synced = false
+		b.resyncs++
+		b.buffer = append(b.buffer[:0], v.Tick)
+		startSync := !b.syncing
+		b.syncing = true
+		b.mutex.Unlock()
+		if startSync {
+			go b.resync()
+		}
+		return
+	}
+	b.mutex.Unlock()
+	b.notify()
+}
+
+// resync 重新同步，失败时等待一段时间后重试，直到成功或订单簿关闭
+func (b *Book) resync() {
+	for {
+		err := b.sync()
+		if err == nil || err == ClosedError {
+			return
+		}
+		debug.Println(b.topic, "resync failed", err)
+		select {
+		case <-time.After(resyncDelay):
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// sync 请求全量快照并应用缓存的增量数据，调用前需要将syncing设置为true
+// 在副本中应用快照和增量数据，成功后才替换当前的订单簿，失败时保留缓存的增量数据以便下次同步
+func (b *Book) sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.market.ReceiveTimeout)
+	defer cancel()
+	rep, err := b.market.RequestWithContext(ctx, b.topic)
+	if err != nil {
+		return err
+	}
+	raw, err := rep.Get("data").Encode()
+	if err != nil {
+		return err
+	}
+	var snapshot data_type.MBPTickDecimal
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return ClosedError
+	}
+	next := depth{levels: b.levels, seqNum: snapshot.SeqNum, fromSnapshot: true}
+	next.update(snapshot)
+	for _, tick := range b.buffer {
+		if err := next.apply(tick); err != nil {
+			// 快照早于缓存的增量数据，等待下次同步
+			return err
+		}
+	}
+	b.depth = next
+	b.buffer = nil
+	b.synced = true
+	b.syncing = false
+	b.notify()
+	return nil
+}
+
+// apply 按序号应用一条增量数据，序号不连续时返回GapError
+func (b *depth) apply(tick data_type.MBPTickDecimal) error {
+	if tick.SeqNum <= b.seqNum {
+		// 已经包含在快照中
+		return nil
+	}
+	if b.fromSnapshot {
+		if tick.PrevSeqNum > b.seqNum {
+			return GapError
+		}
+	} else if tick.PrevSeqNum != b.seqNum {
+		return GapError
+	}
+	b.update(tick)
+	b.seqNum = tick.SeqNum
+	b.fromSnapshot = false
+	return nil
+}
+
+// update 更新各档价格，数量为0时删除该档
+func (b *depth) update(tick data_type.MBPTickDecimal) {
+	for _, v := range tick.Bids {
+		if len(v) >= 2 {
+			b.bids = setLevel(b.bids, v[0], v[1], true)
+		}
+	}
+	for _, v := range tick.Asks {
+		if len(v) >= 2 {
+			b.asks = setLevel(b.asks, v[0], v[1], false)
+		}
+	}
+	if b.levels > 0 {
+		if len(b.bids) > b.levels {
+			b.bids = b.bids[:b.levels]
+		}
+		if len(b.asks) > b.levels {
+			b.asks = b.asks[:b.levels]
+		}
+	}
+}
+
+// setLevel 在有序的档位列表中设置价格price的数量，desc表示价格从高到低排列
+func setLevel(levels []Level, price, amount data_type.Decimal, desc bool) []Level {
+	i := sort.Search(len(levels), func(i int) bool {
+		c := levels[i].Price.Cmp(price)
+		if desc {
+			return c <= 0
+		}
+		return c >= 0
+	})
+	found := i < len(levels) && levels[i].Price.Equal(price)
+	switch {
+	case amount.IsZero():
+		if found {
+			levels = append(levels[:i], levels[i+1:]...)
+		}
+	case found:
+		levels[i].Amount = amount
+	default:
+		levels = append(levels, Level{})
+		copy(levels[i+1:], levels[i:])
+		levels[i] = Level{Price: price, Amount: amount}
+	}
+	return levels
+}
+
+// notify 发送变化通知，不阻塞
+func (b *Book) notify() {
+	select {
+	case b.updated <- struct{}{}:
+	default:
+	}
+}
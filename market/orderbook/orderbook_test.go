@@ -0,0 +1,168 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/market"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTopic = "market.btcusdt.mbp.5"
+
+func waitFor(t *testing.T, cond func() bool) {
+	if !markettest.WaitFor(5*time.Second, cond) {
+		t.Fatal("timeout")
+	}
+}
+
+func prices(levels []Level) []string {
+	ret := make([]string, len(levels))
+	for i, l := range levels {
+		ret[i] = l.Price.String() + ":" + l.Amount.String()
+	}
+	return ret
+}
+
+func TestSetLevel(t *testing.T) {
+	d := data_type.MustParseDecimal
+	var bids []Level
+	bids = setLevel(bids, d("10"), d("1"), true)
+	bids = setLevel(bids, d("12"), d("2"), true)
+	bids = setLevel(bids, d("11"), d("3"), true)
+	assert.Equal(t, []string{"12:2", "11:3", "10:1"}, prices(bids))
+	bids = setLevel(bids, d("11.0"), d("4"), true)
+	bids = setLevel(bids, d("12"), d("0"), true)
+	bids = setLevel(bids, d("9"), d("0"), true)
+	assert.Equal(t, []string{"11:4", "10:1"}, prices(bids))
+
+	var asks []Level
+	asks = setLevel(asks, d("12"), d("1"), false)
+	asks = setLevel(asks, d("10"), d("1"), false)
+	asks = setLevel(asks, d("11"), d("1"), false)
+	assert.Equal(t, []string{"10:1", "11:1", "12:1"}, prices(asks))
+}
+
+func TestBook(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	// 快照之前收到的增量数据被缓存，对齐后应用序号大于快照的部分
+	server.OnSubscribe(testTopic,
+		`{"seqNum":99,"prevSeqNum":98,"bids":[[100,9]],"asks":[]}`,
+		`{"seqNum":101,"prevSeqNum":99,"bids":[[100,0],[99.5,2]],"asks":[]}`,
+		`{"seqNum":102,"prevSeqNum":101,"bids":[],"asks":[[101,3]]}`,
+	)
+	server.SetResponse(testTopic, `{"seqNum":100,"bids":[[100,1],[99,1]],"asks":[[101,1],[102,1]]}`)
+
+	m, err := market.NewMarketWithOptions(market.WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	b, err := New(m, "btcusdt", 5)
+	assert.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, testTopic, b.Topic())
+	waitFor(t, func() bool { return b.SeqNum() == 102 })
+	assert.True(t, b.Synced())
+	assert.Equal(t, []string{"99.5:2", "99:1"}, prices(b.Bids(0)))
+	assert.Equal(t, []string{"101:3", "102:1"}, prices(b.Asks(0)))
+	bid, ok := b.BestBid()
+	assert.True(t, ok)
+	assert.Equal(t, "99.5", bid.Price.String())
+
+	// 连续的增量数据
+	<-b.Updated()
+	server.Publish(testTopic, `{"seqNum":103,"prevSeqNum":102,"bids":[[100.5,1]],"asks":[[101,0]]}`)
+	<-b.Updated()
+	assert.Equal(t, uint(103), b.SeqNum())
+	bid, _ = b.BestBid()
+	ask, _ := b.BestAsk()
+	assert.Equal(t, "100.5", bid.Price.String())
+	assert.Equal(t, "102", ask.Price.String())
+	assert.Equal(t, []string{"100.5:1"}, prices(b.Bids(1)))
+
+	// 序号不连续时重新请求快照
+	server.SetResponse(testTopic, `{"seqNum":200,"bids":[[98,1]],"asks":[[103,1]]}`)
+	server.Publish(testTopic, `{"seqNum":110,"prevSeqNum":109,"bids":[[100,1]],"asks":[]}`)
+	waitFor(t, func() bool { return b.Synced() && b.SeqNum() == 200 })
+	assert.Equal(t, 1, b.Resyncs())
+	assert.Equal(t, 2, server.Requests(testTopic))
+	assert.Equal(t, []string{"98:1"}, prices(b.Bids(0)))
+	assert.Equal(t, []string{"103:1"}, prices(b.Asks(0)))
+
+	server.Publish(testTopic, `{"seqNum":201,"prevSeqNum":200,"bids":[[98,2]],"asks":[]}`)
+	waitFor(t, func() bool { return b.SeqNum() == 201 })
+	assert.Equal(t, []string{"98:2"}, prices(b.Bids(0)))
+
+	b.Close()
+	waitFor(t, func() bool { return server.Subscribers(testTopic) == 0 })
+}
+
+func TestBook_Levels(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetResponse(testTopic, `{"seqNum":1,"bids":[[6,1],[5,1],[4,1],[3,1],[2,1],[1,1]],"asks":[]}`)
+
+	m, err := market.NewMarketWithOptions(market.WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	b, err := New(m, "btcusdt", 5)
+	assert.NoError(t, err)
+	defer b.Close()
+	assert.Len(t, b.Bids(0), 5)
+	assert.Len(t, b.Bids(10), 5)
+	_, ok := b.BestAsk()
+	assert.False(t, ok)
+}
+
+func TestBook_SnapshotError(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetError(testTopic, "bad-request", "invalid topic")
+
+	m, err := market.NewMarketWithOptions(market.WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	_, err = New(m, "btcusdt", 5)
+	assert.Error(t, err)
+}
+
+func TestBook_InitialGap(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.OnSubscribe(testTopic, `{"seqNum":101,"prevSeqNum":100,"bids":[[100,2]],"asks":[]}`)
+	// 第一次返回的快照早于缓存的增量数据
+	snapshots := []string{`{"seqNum":50,"bids":[[90,1]],"asks":[]}`, `{"seqNum":100,"bids":[[100,1],[99,1]],"asks":[]}`}
+	server.HandleRequest(testTopic, func(r *markettest.Request) (string, error) {
+		snapshot := snapshots[0]
+		if len(snapshots) > 1 {
+			snapshots = snapshots[1:]
+		}
+		return snapshot, nil
+	})
+
+	m, err := market.NewMarketWithOptions(market.WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	b, err := New(m, "btcusdt", 5)
+	assert.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 2, server.Requests(testTopic))
+	assert.True(t, b.Synced())
+	assert.Equal(t, uint(101), b.SeqNum())
+	assert.Equal(t, []string{"100:2", "99:1"}, prices(b.Bids(0)))
+
+	// 超过最多尝试次数后返回GapError，取消订阅
+	topic := "market.ethusdt.mbp.5"
+	server.OnSubscribe(topic, `{"seqNum":101,"prevSeqNum":100,"bids":[[100,2]],"asks":[]}`)
+	server.SetResponse(topic, `{"seqNum":50,"bids":[],"asks":[]}`)
+	_, err = New(m, "ethusdt", 5)
+	assert.Equal(t, GapError, err)
+	assert.Equal(t, maxInitialSyncs, server.Requests(topic))
+	waitFor(t, func() bool { return server.Subscribers(topic) == 0 })
+}
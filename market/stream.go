@@ -0,0 +1,286 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/debug"
+)
+
+// OverflowPolicy 通道缓冲区已满时的处理方式
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest 丢弃缓冲区中最旧的消息
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropNewest 丢弃新收到的消息
+	OverflowDropNewest
+	// OverflowBlock 阻塞等待直到有空间，会阻塞消息处理循环，消费过慢时可能导致心跳超时
+	OverflowBlock
+)
+
+// DefaultStreamBuffer 订阅通道默认的缓冲区大小
+const DefaultStreamBuffer = 64
+
+type streamOptions struct {
+	buffer   int
+	overflow OverflowPolicy
+}
+
+// StreamOption 订阅通道选项
+type StreamOption func(o *streamOptions)
+
+// WithBuffer 设置通道缓冲区大小，默认为DefaultStreamBuffer
+// 为0时使用无缓冲的通道，除OverflowBlock外接收方未在等待时消息会被丢弃
+func WithBuffer(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.buffer = n
+	}
+}
+
+// WithOverflow 设置缓冲区已满时的处理方式，默认为OverflowDropOldest
+func WithOverflow(p OverflowPolicy) StreamOption {
+	return func(o *streamOptions) {
+		o.overflow = p
+	}
+}
+
+func newStreamOptions(options []StreamOption) *streamOptions {
+	o := &streamOptions{buffer: DefaultStreamBuffer, overflow: OverflowDropOldest}
+	for _, fn := range options {
+		fn(o)
+	}
+	if o.buffer < 0 {
+		o.buffer = 0
+	}
+	return o
+}
+
+// Subscription 通道订阅，用于取消订阅和查看丢弃的消息数量
+type Subscription struct {
+	// 使用原子操作读写，放在结构体开头以保证64位对齐
+	dropped uint64
+
+	handle    *ListenerHandle
+	overflow  OverflowPolicy
+	ch        reflect.Value
+	decode    func(raw []byte) (interface{}, error)
+	done      chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	closed    bool
+}
+
+// Topic 订阅的主题
+func (s *Subscription) Topic() string {
+	return s.handle.Topic()
+}
+
+// Dropped 因缓冲区已满而丢弃的消息数量
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Done 订阅结束时关闭
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Unsubscribe 关闭通道并取消订阅，主题没有其他监听器时等待取消订阅结果，最多等待ReceiveTimeout
+func (s *Subscription) Unsubscribe() error {
+	s.handle.market.removeStream(s)
+	s.close()
+	return s.handle.Unsubscribe()
+}
+
+// UnsubscribeWithContext 关闭通道并取消订阅，ctx取消或超时时停止等待取消订阅结果
+func (s *Subscription) UnsubscribeWithContext(ctx context.Context) error {
+	s.handle.market.removeStream(s)
+	s.close()
+	return s.handle.UnsubscribeWithContext(ctx)
+}
+
+// close 关闭通道，可重复调用
+func (s *Subscription) close() {
+	s.closeOnce.Do(func() {
+		// 先结束等待中的阻塞发送，再关闭通道
+		close(s.done)
+		s.mutex.Lock()
+		s.closed = true
+		s.ch.Close()
+		s.mutex.Unlock()
+	})
+}
+
+// receive 解码消息并投递到通道
+func (s *Subscription) receive(topic string, json *simplejson.Json) {
+	raw, err := json.Encode()
+	if err != nil {
+		debug.Println(err)
+		return
+	}
+	v, err := s.decode(raw)
+	if err != nil {
+		debug.Println("decode failed", topic, err)
+		return
+	}
+	s.deliver(reflect.ValueOf(v))
+}
+
+// deliver 按溢出策略发送到通道
+func (s *Subscription) deliver(v reflect.Value) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	if s.overflow == OverflowBlock {
+		reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: s.ch, Send: v},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.done)},
+		})
+		return
+	}
+	for !s.ch.TrySend(v) {
+		atomic.AddUint64(&s.dropped, 1)
+		if s.overflow == OverflowDropNewest {
+			return
+		}
+		// 无缓冲的通道没有可丢弃的旧消息，接收方未就绪时丢弃新消息
+		if _, ok := s.ch.TryRecv(); !ok {
+			return
+		}
+	}
+}
+
+// subscribeStream 订阅topic，将消息解码后发送到ch
+func (m *Market) subscribeStream(topic string, ch interface{}, o *streamOptions, decode func(raw []byte) (interface{}, error)) (*Subscription, error) {
+	s := &Subscription{
+		overflow: o.overflow,
+		ch:       reflect.ValueOf(ch),
+		decode:   decode,
+		done:     make(chan struct{}),
+	}
+	h, err := m.AddListener(topic, s.receive)
+	if err != nil {
+		return nil, err
+	}
+	s.handle = h
+	m.addStream(s)
+	return s, nil
+}
+
+// addStream 记录通道订阅，以便关闭连接时关闭通道
+func (m *Market) addStream(s *Subscription) {
+	m.listenerMutex.Lock()
+	m.streams[s] = struct{}{}
+	m.listenerMutex.Unlock()
+}
+
+// removeStream 移除通道订阅
+func (m *Market) removeStream(s *Subscription) {
+	m.listenerMutex.Lock()
+	delete(m.streams, s)
+	m.listenerMutex.Unlock()
+}
+
+// closeStreams 关闭所有通道订阅
+func (m *Market) closeStreams() {
+	m.listenerMutex.Lock()
+	streams := m.streams
+	m.streams = make(map[*Subscription]struct{})
+	m.listenerMutex.Unlock()
+	for s := range streams {
+		s.close()
+	}
+}
+
+// SubscribeKline 订阅K线，period为K线周期，例如client.KlinePeriod1Min
+func (m *Market) SubscribeKline(symbol, period string, options ...StreamOption) (<-chan data_type.Kline, *Subscription, error) {
+	o := newStreamOptions(options)
+	ch := make(chan data_type.Kline, o.buffer)
+	s, err := m.subscribeStream(fmt.Sprintf("market.%s.kline.%s", symbol, period), ch, o, func(raw []byte) (interface{}, error) {
+		v, err := data_type.DecodeKline(raw)
+		if err != nil {
+			return nil, err
+		}
+		return *v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, s, nil
+}
+
+// SubscribeDepth 订阅深度，step为合并深度类型，例如step0
+func (m *Market) SubscribeDepth(symbol, step string, options ...StreamOption) (<-chan data_type.Depth, *Subscription, error) {
+	o := newStreamOptions(options)
+	ch := make(chan data_type.Depth, o.buffer)
+	s, err := m.subscribeStream(fmt.Sprintf("market.%s.depth.%s", symbol, step), ch, o, func(raw []byte) (interface{}, error) {
+		v, err := data_type.DecodeDepth(raw)
+		if err != nil {
+			return nil, err
+		}
+		return *v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, s, nil
+}
+
+// SubscribeTrades 订阅成交明细
+func (m *Market) SubscribeTrades(symbol string, options ...StreamOption) (<-chan data_type.Trade, *Subscription, error) {
+	o := newStreamOptions(options)
+	ch := make(chan data_type.Trade, o.buffer)
+	s, err := m.subscribeStream(fmt.Sprintf("market.%s.trade.detail", symbol), ch, o, func(raw []byte) (interface{}, error) {
+		v, err := data_type.DecodeTrade(raw)
+		if err != nil {
+			return nil, err
+		}
+		return *v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, s, nil
+}
+
+// SubscribeDetail 订阅24小时行情概要
+func (m *Market) SubscribeDetail(symbol string, options ...StreamOption) (<-chan data_type.MarketDetail, *Subscription, error) {
+	o := newStreamOptions(options)
+	ch := make(chan data_type.MarketDetail, o.buffer)
+	s, err := m.subscribeStream(fmt.Sprintf("market.%s.detail", symbol), ch, o, func(raw []byte) (interface{}, error) {
+		v, err := data_type.DecodeMarketDetail(raw)
+		if err != nil {
+			return nil, err
+		}
+		return *v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, s, nil
+}
+
+// SubscribeBBO 订阅买一卖一行情
+func (m *Market) SubscribeBBO(symbol string, options ...StreamOption) (<-chan data_type.BBO, *Subscription, error) {
+	o := newStreamOptions(options)
+	ch := make(chan data_type.BBO, o.buffer)
+	s, err := m.subscribeStream(fmt.Sprintf("market.%s.bbo", symbol), ch, o, func(raw []byte) (interface{}, error) {
+		v, err := data_type.DecodeBBO(raw)
+		if err != nil {
+			return nil, err
+		}
+		return *v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, s, nil
+}
@@ -0,0 +1,313 @@
+package market
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/data_type"
+	"github.com/leizongmin/huobiapi/market/markettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSubscription(ch interface{}, overflow OverflowPolicy) *Subscription {
+	return &Subscription{
+		overflow: overflow,
+		ch:       reflect.ValueOf(ch),
+		done:     make(chan struct{}),
+	}
+}
+
+func TestSubscription_Overflow(t *testing.T) {
+	ch := make(chan int, 2)
+	s := newTestSubscription(ch, OverflowDropOldest)
+	for i := 1; i <= 4; i++ {
+		s.deliver(reflect.ValueOf(i))
+	}
+	assert.Equal(t, uint64(2), s.Dropped())
+	assert.Equal(t, 3, <-ch)
+	assert.Equal(t, 4, <-ch)
+
+	ch = make(chan int, 2)
+	s = newTestSubscription(ch, OverflowDropNewest)
+	for i := 1; i <= 4; i++ {
+		s.deliver(reflect.ValueOf(i))
+	}
+	assert.Equal(t, uint64(2), s.Dropped())
+	assert.Equal(t, 1, <-ch)
+	assert.Equal(t, 2, <-ch)
+
+	// 无缓冲的通道在接收方未就绪时丢弃消息，不会一直循环
+	for _, overflow := range []OverflowPolicy{OverflowDropOldest, OverflowDropNewest} {
+		ch = make(chan int)
+		s = newTestSubscription(ch, overflow)
+		done := make(chan struct{})
+		go func() {
+			for i := 1; i <= 3; i++ {
+				s.deliver(reflect.ValueOf(i))
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("deliver to unbuffered channel did not return")
+		}
+		assert.Equal(t, uint64(3), s.Dropped())
+		received := make(chan int)
+		go func() { received <- <-ch }()
+		// 接收方就绪后可以正常投递
+		for dropped := s.Dropped(); ; dropped++ {
+			s.deliver(reflect.ValueOf(4))
+			if s.Dropped() == dropped {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, 4, <-received)
+	}
+
+	ch = make(chan int)
+	s = newTestSubscription(ch, OverflowBlock)
+	go func() {
+		assert.Equal(t, 1, <-ch)
+	}()
+	s.deliver(reflect.ValueOf(1))
+	blocked := make(chan struct{})
+	go func() {
+		s.deliver(reflect.ValueOf(2))
+		close(blocked)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	s.close()
+	<-blocked
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), s.Dropped())
+
+	// 关闭后不再投递，也不会panic
+	s.deliver(reflect.ValueOf(3))
+	s.close()
+}
+
+func TestMarket_SubscribeKline(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.OnSubscribe("market.eosusdt.kline.1min", testKlineTick)
+	server.OnSubscribe("market.eosusdt.bbo", `{"symbol":"eosusdt","quoteTime":1516870811101,"bid":14.29,"bidSize":21.5757,"ask":14.3,"askSize":1193.5857,"seqId":10242474683}`)
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	klines, sub, err := m.SubscribeKline("eosusdt", "1min", WithBuffer(1), WithOverflow(OverflowDropNewest))
+	assert.NoError(t, err)
+	assert.Equal(t, "market.eosusdt.kline.1min", sub.Topic())
+	bbos, _, err := m.SubscribeBBO("eosusdt")
+	assert.NoError(t, err)
+
+	var kline data_type.Kline
+	select {
+	case kline = <-klines:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting kline")
+	}
+	assert.Equal(t, 14.3, kline.Tick.High)
+	assert.Equal(t, uint(1516870800), kline.Tick.ID)
+
+	select {
+	case bbo := <-bbos:
+		assert.Equal(t, 14.29, bbo.Tick.Bid)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting bbo")
+	}
+
+	sub.Unsubscribe()
+	_, ok := <-klines
+	assert.False(t, ok)
+
+	m.Close()
+	_, ok = <-bbos
+	assert.False(t, ok)
+}
+
+func TestMarket_AddListener(t *testing.T) {
+	topic := "market.eosusdt.kline.1min"
+	server := markettest.NewServer()
+	defer server.Close()
+	server.OnSubscribe(topic, `{"id":1516870800,"close":14.29}`)
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	received := make(chan int, 10)
+	h1, err := m.AddListener(topic, func(topic string, json *simplejson.Json) { received <- 1 })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, <-received)
+	h2, err := m.AddListener(topic, func(topic string, json *simplejson.Json) { received <- 2 })
+	assert.NoError(t, err)
+	klines, sub, err := m.SubscribeKline("eosusdt", "1min")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.Subs(topic))
+
+	// 推送一条消息，所有监听器都会收到
+	assert.Equal(t, 1, server.Publish(topic, `{"id":1516870860,"close":14.3}`))
+	assert.Equal(t, 1, <-received)
+	assert.Equal(t, 2, <-received)
+	assert.Equal(t, 14.3, (<-klines).Tick.Close)
+
+	h1.Unsubscribe()
+	sub.Unsubscribe()
+	server.Publish(topic, `{"id":1516870920,"close":14.31}`)
+	assert.Equal(t, 2, <-received)
+	assert.Len(t, received, 0)
+
+	// 最后一个监听器移除后，再次添加时重新发送订阅指令
+	h2.Unsubscribe()
+	_, err = m.AddListener(topic, func(topic string, json *simplejson.Json) {})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.Subs(topic))
+}
+
+func TestMarket_AddListener_Concurrent(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetAckDelay(100 * time.Millisecond)
+	ok, failed := "market.eosusdt.kline.1min", "market.xyzusdt.kline.1min"
+	server.SetError(failed, "bad-request", "invalid topic")
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// 同时添加的监听器等待同一个订阅结果
+	addListeners := func(topic string, received chan string) []error {
+		errs := make([]error, 3)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = m.AddListener(topic, func(topic string, json *simplejson.Json) { received <- topic })
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	received := make(chan string, 10)
+	for _, err := range addListeners(failed, received) {
+		assert.EqualError(t, err, "invalid topic")
+	}
+	assert.Equal(t, 1, server.Subs(failed))
+	m.listenerMutex.Lock()
+	assert.Empty(t, m.listeners[failed])
+	assert.Nil(t, m.subscribedTopic[failed])
+	m.listenerMutex.Unlock()
+
+	for _, err := range addListeners(ok, received) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, server.Subs(ok))
+	assert.Equal(t, 1, server.Publish(ok, `{"id":1516870860,"close":14.3}`))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ok, <-received)
+	}
+
+	// 等待订阅结果的监听器可以单独放弃等待
+	topic := "market.eosusdt.detail"
+	first := make(chan error)
+	go func() {
+		_, err := m.AddListener(topic, func(topic string, json *simplejson.Json) {})
+		first <- err
+	}()
+	waitFor(t, func() bool { return server.Subs(topic) == 1 })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = m.AddListenerWithContext(ctx, topic, func(topic string, json *simplejson.Json) {})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.NoError(t, <-first)
+	m.listenerMutex.Lock()
+	assert.Len(t, m.listeners[topic], 1)
+	m.listenerMutex.Unlock()
+}
+
+func TestMarket_Unsubscribe(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	kline, detail := "market.eosusdt.kline.1min", "market.eosusdt.detail"
+	assert.NoError(t, m.Subscribe(kline, func(topic string, json *simplejson.Json) {}))
+	assert.NoError(t, m.Subscribe(detail, func(topic string, json *simplejson.Json) {}))
+	klines, _, err := m.SubscribeKline("eosusdt", "1min")
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Unsubscribe(kline))
+	assert.Equal(t, 1, server.Unsubs(kline))
+	_, ok := <-klines
+	assert.False(t, ok)
+	// 未订阅的主题不发送取消订阅指令
+	assert.NoError(t, m.Unsubscribe(kline))
+	assert.Equal(t, 1, server.Unsubs(kline))
+
+	// 重连时只重新订阅仍在监听的主题
+	_, ok = m.listeners[kline]
+	assert.False(t, ok)
+	_, ok = m.listeners[detail]
+	assert.True(t, ok)
+
+	// 最后一个监听器移除后发送取消订阅指令
+	h, err := m.AddListener(detail, func(topic string, json *simplejson.Json) {})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Unsubscribe(detail))
+	h.Unsubscribe()
+	assert.Equal(t, 1, server.Unsubs(detail))
+}
+
+func TestListenerHandle_Unsubscribe(t *testing.T) {
+	server := markettest.NewServer()
+	defer server.Close()
+	server.SetAckDelay(50 * time.Millisecond)
+
+	m, err := NewMarketWithOptions(WithEndpoint(server.URL))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// 最后一个监听器移除时等待取消订阅结果后返回
+	topic := "market.eosusdt.kline.1min"
+	h, err := m.AddListener(topic, func(topic string, json *simplejson.Json) {})
+	assert.NoError(t, err)
+	_, sub, err := m.SubscribeKline("eosusdt", "1min")
+	assert.NoError(t, err)
+	assert.NoError(t, h.Unsubscribe())
+	assert.Equal(t, 0, server.Unsubs(topic))
+	assert.NoError(t, sub.Unsubscribe())
+	assert.Equal(t, 1, server.Unsubs(topic))
+	assert.Equal(t, 0, server.Subscribers(topic))
+
+	// 取消订阅失败时返回错误
+	server.SetAckDelay(0)
+	topic = "market.eosusdt.detail"
+	h, err = m.AddListener(topic, func(topic string, json *simplejson.Json) {})
+	assert.NoError(t, err)
+	assert.NoError(t, m.sendUnsubscribe(context.Background(), topic))
+	assert.EqualError(t, h.Unsubscribe(), "unsub with not subbed topic "+topic)
+
+	// ctx超时时停止等待，监听器仍然被移除
+	topic = "market.eosusdt.bbo"
+	h, err = m.AddListener(topic, func(topic string, json *simplejson.Json) {})
+	assert.NoError(t, err)
+	server.SetAckDelay(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, h.UnsubscribeWithContext(ctx))
+	m.listenerMutex.Lock()
+	assert.Empty(t, m.listeners[topic])
+	m.listenerMutex.Unlock()
+}
@@ -6,6 +6,9 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/leizongmin/huobiapi/client"
 )
 
 var letterRunes = []rune("1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -20,8 +23,8 @@ func getRandomString(n uint) string {
 }
 
 // getUinxMillisecond 取毫秒时间戳
-func getUinxMillisecond() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+func getUinxMillisecond(clock client.Clock) int64 {
+	return clock.Now().UnixNano() / int64(time.Millisecond)
 }
 
 // unGzipData 解压gzip的数据
@@ -32,3 +35,14 @@ func unGzipData(buf []byte) ([]byte, error) {
 	}
 	return ioutil.ReadAll(r)
 }
+
+// newAPIError 根据返回的错误消息创建client.APIError
+func newAPIError(topic string, json *simplejson.Json) *client.APIError {
+	body, _ := json.Encode()
+	return &client.APIError{
+		Code:    json.Get("err-code").MustString(),
+		Message: json.Get("err-msg").MustString(),
+		Path:    topic,
+		Body:    body,
+	}
+}
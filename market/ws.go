@@ -2,6 +2,8 @@ package market
 
 import (
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -11,16 +13,22 @@ var SafeWebSocketDestroyError = fmt.Errorf("connection destroy by user")
 
 // SafeWebSocket 安全的WebSocket封装
 // 保证读取和发送操作是并发安全的，支持自定义保持alive函数
+// 读取、发送和保持alive分别在独立的goroutine中执行，连接出错或被销毁后全部退出
 type SafeWebSocket struct {
-	ws               *websocket.Conn
-	listener         SafeWebSocketMessageListener
-	aliveHandler     SafeWebSocketAliveHandler
-	aliveInterval    time.Duration
-	sendQueue        chan []byte
-	lastError        error
-	runningTaskSend  bool
-	runningTaskRead  bool
-	runningTaskAlive bool
+	ws        *websocket.Conn
+	sendQueue chan []byte
+	// 连接出错或被销毁时关闭
+	done      chan struct{}
+	closeOnce sync.Once
+	// alive参数被修改时通知保持alive的goroutine
+	aliveChanged chan struct{}
+
+	mutex         sync.Mutex
+	listener      SafeWebSocketMessageListener
+	aliveHandler  SafeWebSocketAliveHandler
+	aliveInterval time.Duration
+	lastError     error
+	closeError    error
 }
 
 type SafeWebSocketMessageListener = func(b []byte)
@@ -28,87 +36,148 @@ type SafeWebSocketAliveHandler = func()
 
 // NewSafeWebSocket 创建安全的WebSocket实例并连接
 func NewSafeWebSocket(endpoint string) (*SafeWebSocket, error) {
-	ws, _, err := websocket.DefaultDialer.Dial(endpoint, nil)
+	return NewSafeWebSocketWithDialer(endpoint, websocket.DefaultDialer, nil)
+}
+
+// NewSafeWebSocketWithDialer 使用指定的Dialer和请求头创建安全的WebSocket实例并连接
+func NewSafeWebSocketWithDialer(endpoint string, dialer *websocket.Dialer, header http.Header) (*SafeWebSocket, error) {
+	ws, _, err := dialer.Dial(endpoint, header)
 	if err != nil {
 		return nil, err
 	}
-	s := &SafeWebSocket{ws: ws, sendQueue: make(chan []byte, 1000), aliveInterval: time.Second * 60}
+	s := &SafeWebSocket{
+		ws:            ws,
+		sendQueue:     make(chan []byte, 1000),
+		done:          make(chan struct{}),
+		aliveChanged:  make(chan struct{}, 1),
+		aliveInterval: time.Second * 60,
+	}
+
+	go s.sendLoop()
+	go s.readLoop()
+	go s.aliveLoop()
 
-	go func() {
-		s.runningTaskSend = true
-		for s.lastError == nil {
-			b := <-s.sendQueue
+	return s, nil
+}
+
+// sendLoop 依次发送队列中的消息，保证同一时间只有一个goroutine写入连接
+func (s *SafeWebSocket) sendLoop() {
+	for {
+		select {
+		case b := <-s.sendQueue:
 			if err := s.ws.WriteMessage(websocket.TextMessage, b); err != nil {
-				s.lastError = err
-				break
+				s.fail(err)
+				return
 			}
+		case <-s.done:
+			return
 		}
-		s.runningTaskSend = false
-	}()
-
-	go func() {
-		s.runningTaskRead = true
-		for s.lastError == nil {
-			_, b, err := s.ws.ReadMessage()
-			if err != nil {
-				s.lastError = err
-				break
-			}
-			s.listener(b)
+	}
+}
+
+// readLoop 读取消息并交给监听器处理
+func (s *SafeWebSocket) readLoop() {
+	for {
+		_, b, err := s.ws.ReadMessage()
+		if err != nil {
+			s.fail(err)
+			return
 		}
-		s.runningTaskRead = false
-	}()
-
-	go func() {
-		s.runningTaskAlive = true
-		for s.lastError == nil {
-			if s.aliveHandler != nil {
-				s.aliveHandler()
-			}
-			time.Sleep(s.aliveInterval)
+		s.mutex.Lock()
+		listener := s.listener
+		s.mutex.Unlock()
+		if listener != nil {
+			listener(b)
 		}
-		s.runningTaskAlive = false
-	}()
+	}
+}
 
-	return s, nil
+// aliveLoop 按aliveInterval周期执行aliveHandler
+func (s *SafeWebSocket) aliveLoop() {
+	for {
+		s.mutex.Lock()
+		handler, interval := s.aliveHandler, s.aliveInterval
+		s.mutex.Unlock()
+		if handler != nil {
+			handler()
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-s.aliveChanged:
+			timer.Stop()
+		case <-s.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// fail 记录错误并关闭连接，只有第一次调用有效
+func (s *SafeWebSocket) fail(err error) {
+	s.closeOnce.Do(func() {
+		s.mutex.Lock()
+		s.lastError = err
+		s.mutex.Unlock()
+		close(s.done)
+		// 关闭连接使阻塞中的读取操作返回
+		closeErr := s.ws.Close()
+		s.mutex.Lock()
+		s.closeError = closeErr
+		s.mutex.Unlock()
+	})
 }
 
 // Listen 监听消息
 func (s *SafeWebSocket) Listen(h SafeWebSocketMessageListener) {
+	s.mutex.Lock()
 	s.listener = h
+	s.mutex.Unlock()
 }
 
-// Send 发送消息
+// Send 发送消息，连接已关闭时丢弃
 func (s *SafeWebSocket) Send(b []byte) {
-	s.sendQueue <- b
+	select {
+	case s.sendQueue <- b:
+	case <-s.done:
+	}
 }
 
-// KeepAlive 设置alive周期及函数
+// KeepAlive 设置alive周期及函数，设置后立即执行一次
 func (s *SafeWebSocket) KeepAlive(v time.Duration, h SafeWebSocketAliveHandler) {
+	s.mutex.Lock()
 	s.aliveInterval = v
 	s.aliveHandler = h
+	s.mutex.Unlock()
+	select {
+	case s.aliveChanged <- struct{}{}:
+	default:
+	}
 }
 
-// Destroy 销毁
+// Destroy 销毁，不等待读取和发送的goroutine退出，可以在监听器中调用
 func (s *SafeWebSocket) Destroy() (err error) {
-	s.lastError = SafeWebSocketDestroyError
-	for !s.runningTaskRead && !s.runningTaskSend && !s.runningTaskAlive {
-		time.Sleep(time.Millisecond * 100)
-	}
-	if s.ws != nil {
-		err = s.ws.Close()
-		s.ws = nil
-	}
-	s.listener = nil
-	s.aliveHandler = nil
-	s.sendQueue = nil
-	return err
+	s.fail(SafeWebSocketDestroyError)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closeError
+}
+
+// Done 连接出错或被销毁时关闭
+func (s *SafeWebSocket) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err 连接出错或被销毁的原因，连接正常时返回nil
+func (s *SafeWebSocket) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastError
 }
 
 // Loop 进入事件循环，直到连接关闭才退出
 func (s *SafeWebSocket) Loop() error {
-	for s.lastError == nil {
-		time.Sleep(time.Millisecond * 100)
-	}
-	return s.lastError
+	<-s.done
+	return s.Err()
 }